@@ -1,13 +1,13 @@
 package cmd
 
 import (
-	"bytes"
 	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strings"
 
+	"github.com/qtumproject/simple-abi/definitions"
 	"github.com/qtumproject/simple-abi/generation"
 	"github.com/qtumproject/simple-abi/parser"
 
@@ -18,14 +18,20 @@ var (
 	abiFilename string
 	encode      bool
 	decode      bool
+	storage     bool
 	language    string
+	templateDir string
+	generators  []string
 )
 
 func init() {
 	rootCmd.PersistentFlags().StringVarP(&abiFilename, "abi", "a", "", "path of simpleabi file; must be in .abi extension; see docs for details")
 	rootCmd.PersistentFlags().BoolVarP(&encode, "encode", "e", false, "enabling this flag generates an encoding abi template")
 	rootCmd.PersistentFlags().BoolVarP(&decode, "decode", "d", false, "enabling this flag generates a decoding abi template")
-	rootCmd.PersistentFlags().StringVarP(&language, "lang", "l", "c", "defines which language you would like to generate in, must be one of: c")
+	rootCmd.PersistentFlags().BoolVarP(&storage, "storage", "s", false, "enabling this flag generates typed accessors for the :storage section")
+	rootCmd.PersistentFlags().StringVarP(&language, "lang", "l", "c", "defines which language you would like to generate in, must be one of: "+strings.Join(generation.Languages(), ", "))
+	rootCmd.PersistentFlags().StringSliceVarP(&generators, "generate", "g", nil, "names of additional generators to run, e.g. --generate encoding,decoding; other names run simpleabi-gen-<name> from PATH")
+	rootCmd.PersistentFlags().StringVarP(&templateDir, "template-dir", "t", "", "directory of <name>.tmpl files overriding built-in templates; see simpleabi templates")
 }
 
 var rootCmd = &cobra.Command{
@@ -33,10 +39,11 @@ var rootCmd = &cobra.Command{
 	Short: "SimpleAbi is a tool for creating non solidity smart contracts for Qtum",
 	Long: `SimpleAbi is a tool that takes in an input file specifically crafted for ABIs (see documentation
 for how to make this properly work) and generates a template for smart contract interaction in a variety of available languages. 
-Current languages available are C but we are adamently working hard at Qtum to add more in.`,
+Current languages available are C, C++, Go, Python, Rust and TypeScript but we are adamently working hard at Qtum to add more in.`,
 	Run: func(cmd *cobra.Command, args []string) {
-		if encode == false && decode == false {
-			fmt.Printf("Must select one of encode or decode (or both) as an option to use this tool\n")
+		selected := selectedGenerators()
+		if len(selected) == 0 {
+			fmt.Printf("Must select at least one of encode, decode, storage or generate as an option to use this tool\n")
 			os.Exit(1)
 		}
 
@@ -50,66 +57,82 @@ Current languages available are C but we are adamently working hard at Qtum to a
 			os.Exit(1)
 		}
 
-		if language != "c" {
-			fmt.Printf("Unexpected language %v selected, select one of: c\n", language)
-			os.Exit(1)
+		for _, name := range selected {
+			if _, err := generation.Lookup(language, name); err != nil {
+				fmt.Printf("Unexpected generator selected: %v\n", err)
+				os.Exit(1)
+			}
+		}
+
+		if templateDir != "" {
+			if err := generation.CheckTemplateDir(templateDir); err != nil {
+				fmt.Printf("Invalid template directory: %v\n", err)
+				os.Exit(1)
+			}
 		}
 
-		interfaceBuilder, err := parser.Parse(abiFilename, false)
+		interfaceBuilders, err := parser.Parse(abiFilename, false)
 		if err != nil {
 			fmt.Printf("Error in parsing your abi file: %v\n", err)
 			os.Exit(1)
 		}
 
-		nameBase := strings.TrimSuffix(interfaceBuilder.ContractName, ".abi")
-
-		if encode {
-			cName := nameBase + "ABI.c"
-			hName := nameBase + "ABI.h"
-			var buf bytes.Buffer
-			err := generation.GenerateTemplate(interfaceBuilder, nameBase+"ABI.c", &buf, generation.EncodeC)
-			if err != nil {
-				fmt.Printf("Error in encoding template generation: %v\n", err)
-			}
-			err = ioutil.WriteFile(cName, buf.Bytes(), 0666)
-			if err != nil {
-				fmt.Printf("Error in file creation and writing: %v\n", err)
-			}
-			buf.Reset()
-			err = generation.GenerateTemplate(interfaceBuilder, nameBase+"ABI.h", &buf, generation.EncodeH)
-			if err != nil {
-				fmt.Printf("Error in encoding template generation: %v\n", err)
-			}
-			err = ioutil.WriteFile(hName, buf.Bytes(), 0666)
-			if err != nil {
-				fmt.Printf("Error in file creation and writing: %v\n", err)
+		var generated bool
+		for _, interfaceBuilder := range interfaceBuilders {
+			// interfaces only describe functions for contracts to implement, they have no code of their own
+			if interfaceBuilder.IsInterface {
+				continue
 			}
+			generateContract(interfaceBuilder, selected)
+			generated = true
+		}
+		if !generated {
+			fmt.Printf("No contract declared in %v, only interfaces\n", abiFilename)
+			os.Exit(1)
 		}
+	},
+}
 
-		if decode {
-			cName := nameBase + "Dispatcher.c"
-			hName := nameBase + "Dispatcher.h"
-			var buf bytes.Buffer
-			err := generation.GenerateTemplate(interfaceBuilder, nameBase+"Dispatcher.c", &buf, generation.DecodeC)
-			if err != nil {
-				fmt.Printf("Error in decoding template generation: %v\n", err)
-			}
-			err = ioutil.WriteFile(cName, buf.Bytes(), 0666)
-			if err != nil {
-				fmt.Printf("Error in file creation and writing: %v\n", err)
-			}
-			buf.Reset()
-			err = generation.GenerateTemplate(interfaceBuilder, nameBase+"Dispatcher.h", &buf, generation.DecodeH)
-			if err != nil {
-				fmt.Printf("Error in decoding template generation: %v\n", err)
+// selectedGenerators returns the names of the generators selected by --encode, --decode, --storage and --generate
+func selectedGenerators() []string {
+	var names []string
+	if encode {
+		names = append(names, "encoding")
+	}
+	if decode {
+		names = append(names, "decoding")
+	}
+	if storage {
+		names = append(names, "storage")
+	}
+	return append(names, generators...)
+}
+
+// generateContract writes the files of the selected generators for a single contract
+func generateContract(interfaceBuilder definitions.QInterfaceBuilder, selected []string) {
+	for _, name := range selected {
+		generator, err := generation.Lookup(language, name)
+		if err != nil {
+			fmt.Printf("Unexpected generator selected: %v\n", err)
+			os.Exit(1)
+		}
+		files, err := generator.Generate(interfaceBuilder, language, generation.Options{TemplateDir: templateDir})
+		if err != nil {
+			fmt.Printf("Error in %v template generation: %v\n", name, err)
+			os.Exit(1)
+		}
+		for _, file := range files {
+			// plugins can place files in subdirectories
+			if err := os.MkdirAll(filepath.Dir(file.Name), 0777); err != nil {
+				fmt.Printf("Error in directory creation: %v\n", err)
+				os.Exit(1)
 			}
-			err = ioutil.WriteFile(hName, buf.Bytes(), 0666)
-			if err != nil {
+			if err := ioutil.WriteFile(file.Name, file.Content, 0666); err != nil {
 				fmt.Printf("Error in file creation and writing: %v\n", err)
+				os.Exit(1)
 			}
 		}
-
-	},
+	}
 }
 
 // Execute runs the root command of the application
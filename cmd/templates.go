@@ -0,0 +1,56 @@
+package cmd
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/qtumproject/simple-abi/generation"
+
+	"github.com/spf13/cobra"
+)
+
+var overwrite bool
+
+func init() {
+	templatesCmd.Flags().BoolVarP(&overwrite, "force", "f", false, "overwrite template files that already exist")
+	rootCmd.AddCommand(templatesCmd)
+}
+
+var templatesCmd = &cobra.Command{
+	Use:   "templates [directory]",
+	Short: "Writes the built-in templates to a directory as a starting point for --template-dir",
+	Long: `Writes every built-in template to the directory, the current one by default, as <name>.tmpl.
+Edit the ones you need, delete the others and pass the directory to --template-dir: the files left
+override the built-in templates of the same name. Templates get the same contract data and helper
+methods as the built-in ones, and helper functions such as camelCase, langType and selector, see the README.`,
+	Args: cobra.MaximumNArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		dir := "."
+		if len(args) == 1 {
+			dir = args[0]
+		}
+		if err := os.MkdirAll(dir, 0777); err != nil {
+			fmt.Printf("Error in creating the template directory: %v\n", err)
+			os.Exit(1)
+		}
+		// check first so that nothing is written when a template would be overwritten
+		for _, name := range generation.TemplateNames() {
+			path := filepath.Join(dir, name+generation.TemplateExt)
+			if _, err := os.Stat(path); err == nil && !overwrite {
+				fmt.Printf("%v already exists, pass --force to overwrite it\n", path)
+				os.Exit(1)
+			}
+		}
+		for _, name := range generation.TemplateNames() {
+			path := filepath.Join(dir, name+generation.TemplateExt)
+			// TemplateNames only lists built-in templates
+			text, _ := generation.BuiltinTemplate(name)
+			if err := ioutil.WriteFile(path, []byte(text), 0666); err != nil {
+				fmt.Printf("Error in file creation and writing: %v\n", err)
+				os.Exit(1)
+			}
+		}
+	},
+}
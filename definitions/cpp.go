@@ -0,0 +1,231 @@
+package definitions
+
+import (
+	"strconv"
+	"strings"
+)
+
+// cppKeywords are the keywords C++ has on top of the C ones the parser rejects, cppName appends an underscore to them
+var cppKeywords = map[string]bool{
+	"alignas": true, "alignof": true, "and": true, "and_eq": true, "asm": true, "bitand": true, "bitor": true,
+	"catch": true, "char8_t": true, "char16_t": true, "char32_t": true, "class": true, "compl": true,
+	"concept": true, "consteval": true, "constexpr": true, "constinit": true, "const_cast": true,
+	"co_await": true, "co_return": true, "co_yield": true, "decltype": true, "delete": true,
+	"dynamic_cast": true, "explicit": true, "export": true, "friend": true, "mutable": true, "namespace": true,
+	"new": true, "noexcept": true, "not": true, "not_eq": true, "nullptr": true, "operator": true, "or": true,
+	"or_eq": true, "private": true, "protected": true, "public": true, "reinterpret_cast": true,
+	"requires": true, "static_assert": true, "static_cast": true, "template": true, "this": true,
+	"thread_local": true, "throw": true, "try": true, "typeid": true, "typename": true, "using": true,
+	"virtual": true, "wchar_t": true, "xor": true, "xor_eq": true,
+}
+
+// cppName is name as a C++ identifier
+func cppName(name string) string {
+	if cppKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// GenDocCpp generates a Doxygen comment for the client method (isEncoding) or the virtual method of the function,
+// indented by indent. It returns an empty string when the function has no documentation
+func (q QFunc) GenDocCpp(indent string, isEncoding bool) string {
+	var lines []string
+	if q.Doc != "" {
+		lines = append(lines, strings.Split(q.Doc, "\n")...)
+	}
+	if deprecated, ok := q.Annotations["deprecated"]; ok && !isEncoding {
+		// client methods carry a [[deprecated]] attribute instead
+		lines = append(lines, strings.TrimSpace("@deprecated "+deprecated))
+	}
+	for _, input := range q.Inputs {
+		if doc := input.paramDoc(); doc != "" && !(isEncoding && isContextType(input.Type)) {
+			lines = append(lines, "@param "+cppName(input.TypeName)+" "+doc)
+		}
+	}
+	for _, output := range q.Outputs {
+		if doc := output.paramDoc(); doc != "" {
+			lines = append(lines, "@return "+cppName(output.TypeName)+" "+doc)
+		}
+	}
+	comment := genDocCommentC(lines)
+	if comment == "" {
+		return ""
+	}
+	return indent + strings.Replace(strings.TrimSuffix(comment, "\n"), "\n", "\n"+indent, -1) + "\n"
+}
+
+// GenResultStructCpp generates the struct holding the outputs of the function, named <function>_result,
+// to be declared inside a class
+func (q QFunc) GenResultStructCpp() string {
+	fields := []string{"struct " + q.CName() + "_result {"}
+	for _, output := range q.Outputs {
+		fields = append(fields, "    "+getCppType(output)+" "+cppName(output.TypeName)+";")
+	}
+	return strings.Join(append(fields, "};"), "\n    ")
+}
+
+// GenClientMethodCpp generates the client method calling the function of a deployed contract. It returns
+// the outputs, or nothing when the call fails, in which case lastCallResult tells why. The stack package
+// documents the order of the inputs and outputs on the stack
+func (q QFunc) GenClientMethodCpp(contractName string) string {
+	var lines []string
+	if deprecated, ok := q.Annotations["deprecated"]; ok {
+		lines = append(lines, "[[deprecated("+strconv.Quote(deprecated)+")]]")
+	}
+	params := []string{"const QtumCallOptions& __options"}
+	for _, input := range q.encodedInputs() {
+		params = append(params, getCppParamType(input)+" "+cppName(input.TypeName))
+	}
+	lines = append(lines, "std::optional<"+q.CName()+"_result> "+cppName(q.CName())+"("+strings.Join(params, ", ")+") {")
+	if !q.Payable {
+		lines = append(lines, "    if(__options.value > 0) {", "        qtumError(\"nonpayable function\");", "    }")
+	}
+	inputs := q.encodedInputs()
+	for i := len(inputs) - 1; i >= 0; i-- {
+		lines = append(lines, "    "+getCppPushStatement(inputs[i], cppName(inputs[i].TypeName)))
+	}
+	lines = append(lines, "    qtumPush32(ID_"+contractName+"_"+q.CName()+");")
+	lines = append(lines, "    __lastCallResult = qtumCall(&__address, &__options);")
+	lines = append(lines, "    if(__lastCallResult.error != QTUM_CALL_SUCCESS) {", "        return std::nullopt;", "    }")
+	lines = append(lines, "    "+q.CName()+"_result __result;")
+	for i := len(q.Outputs) - 1; i >= 0; i-- {
+		lines = append(lines, getCppPopStatements("    ", q.Outputs[i], "__result."+cppName(q.Outputs[i].TypeName))...)
+	}
+	lines = append(lines, "    return __result;", "}")
+	return strings.Join(lines, "\n    ")
+}
+
+// GenImplMethodCpp generates the pure virtual method implementing the function. It returns nothing,
+// the only output or, when there are several outputs, the function's result struct
+func (q QFunc) GenImplMethodCpp() string {
+	var params []string
+	for _, input := range q.Inputs {
+		params = append(params, getCppParamType(input)+" "+cppName(input.TypeName))
+	}
+	return "virtual " + q.cppReturnType() + " " + cppName(q.CName()) + "(" + strings.Join(params, ", ") + ") = 0;"
+}
+
+// GenDispatchCodeCpp generates the case body dispatching a call to the function, see GenDispatchCodeC
+func (q QFunc) GenDispatchCodeCpp(contractName string) string {
+	var statement []string
+	if !q.Payable {
+		statement = append(statement, "if(qtumExec->valueSent > 0) {", "    qtumError(\"nonpayable function\");", "}")
+	}
+	if len(q.OnlyRoles) > 0 {
+		var checks []string
+		for _, role := range q.OnlyRoles {
+			statement = append(statement, "UniversalAddressABI __role_"+role+" = __contract.role_"+role+"();")
+			checks = append(checks, "memcmp(&__role_"+role+", &qtumExec->sender, sizeof(UniversalAddressABI)) != 0")
+		}
+		statement = append(statement, "if("+strings.Join(checks, " && ")+") {")
+		statement = append(statement, "    qtumError(\"unauthorized: only "+strings.Join(q.OnlyRoles, " or ")+"\");", "}")
+	}
+	var args []string
+	for _, input := range q.Inputs {
+		name := cppName(input.TypeName)
+		args = append(args, name)
+		if isContextType(input.Type) {
+			statement = append(statement, getContextTypeC(input.Type)+" "+name+" = "+getContextValueC(input.Type)+";")
+			continue
+		}
+		if maxlen, ok := input.Annotations["maxlen"]; ok {
+			statement = append(statement, "if(qtumPeekSize() > "+maxlen+" * sizeof("+getCBaseType(getBaseType(input.Type))+")) {")
+			statement = append(statement, "    qtumError(\""+input.TypeName+" is longer than "+maxlen+" elements\");", "}")
+		}
+		if isIntegerType(input.Type) {
+			statement = append(statement, getCppType(input)+" "+name+" = "+getQtumPopStatement(input.Type)+";")
+			continue
+		}
+		statement = append(statement, getCppType(input)+" "+name+";")
+		statement = append(statement, getCppPopStatements("", input, name)...)
+	}
+	if q.NonReentrant {
+		statement = append(statement, contractName+"_nonreentrant_enter();")
+	}
+	call := "__contract." + cppName(q.CName()) + "(" + strings.Join(args, ", ") + ");"
+	switch len(q.Outputs) {
+	case 0:
+		statement = append(statement, call)
+	case 1:
+		statement = append(statement, getCppType(q.Outputs[0])+" "+cppName(q.Outputs[0].TypeName)+" = "+call)
+	default:
+		statement = append(statement, contractName+"Impl::"+q.CName()+"_result __result = "+call)
+	}
+	if q.NonReentrant {
+		statement = append(statement, contractName+"_nonreentrant_exit();")
+	}
+	for _, output := range q.Outputs {
+		value := cppName(output.TypeName)
+		if len(q.Outputs) > 1 {
+			value = "__result." + value
+		}
+		statement = append(statement, getCppPushStatement(output, value))
+	}
+	statement = append(statement, "break;")
+	return strings.Join(statement, "\n        ")
+}
+
+func (q QFunc) cppReturnType() string {
+	switch len(q.Outputs) {
+	case 0:
+		return "void"
+	case 1:
+		return getCppType(q.Outputs[0])
+	default:
+		return q.CName() + "_result"
+	}
+}
+
+// getCppType is the C++ type holding an input or output
+func getCppType(typ QType) string {
+	switch {
+	case isContextType(typ.Type):
+		return getContextTypeC(typ.Type)
+	case isFixedArray(typ.Type):
+		return "std::array<" + getCBaseType(getBaseType(typ.Type)) + ", " + strconv.Itoa(typ.Length) + ">"
+	case isArray(typ.Type):
+		return "std::vector<" + getCBaseType(getBaseType(typ.Type)) + ">"
+	default:
+		return getCBaseType(typ.Type)
+	}
+}
+
+// getCppParamType is the type methods take an input as, by const reference for anything that is not an integer
+func getCppParamType(typ QType) string {
+	if isContextType(typ.Type) || isIntegerType(typ.Type) {
+		return getCppType(typ)
+	}
+	return "const " + getCppType(typ) + "&"
+}
+
+// getCppPushStatement pushes value onto the call stack, the same way the C code does
+func getCppPushStatement(typ QType, value string) string {
+	switch {
+	case isFixedArray(typ.Type):
+		return "qtumPush(" + value + ".data(), sizeof(" + value + "));"
+	case isArray(typ.Type):
+		return "qtumPush(" + value + ".data(), " + value + ".size() * sizeof(" + getCBaseType(getBaseType(typ.Type)) + "));"
+	case typ.Type == "uniaddress":
+		return "qtumPush(&" + value + ", sizeof(UniversalAddressABI));"
+	default:
+		return getQtumPushStatement(typ.Type) + "(" + value + ");"
+	}
+}
+
+// getCppPopStatements pops an input or output off the call stack into the already declared target
+func getCppPopStatements(indent string, typ QType, target string) []string {
+	switch {
+	case isFixedArray(typ.Type):
+		return []string{indent + "qtumPop(" + target + ".data(), sizeof(" + target + "));"}
+	case isArray(typ.Type):
+		return []string{
+			indent + target + ".resize(qtumPeekSize() / sizeof(" + getCBaseType(getBaseType(typ.Type)) + "));",
+			indent + "qtumPop(" + target + ".data(), " + target + ".size() * sizeof(" + getCBaseType(getBaseType(typ.Type)) + "));",
+		}
+	case typ.Type == "uniaddress":
+		return []string{indent + "qtumPopExact(&" + target + ", sizeof(UniversalAddressABI));"}
+	default:
+		return []string{indent + target + " = " + getQtumPopStatement(typ.Type) + ";"}
+	}
+}
@@ -3,6 +3,8 @@ package definitions
 import (
 	"crypto/sha256"
 	"fmt"
+	"sort"
+	"strconv"
 	"strings"
 )
 
@@ -11,6 +13,92 @@ import (
 type QInterfaceBuilder struct {
 	ContractName string
 	Functions    []QFunc
+	// IsInterface marks an abstract :interface= block, which describes functions but generates no code of its own
+	IsInterface bool
+	// Implements lists the abstract interfaces the contract has been verified to conform to
+	Implements []string
+	// Roles are the access control roles declared with :role=, each backed by a ContractName_role_<role> accessor
+	Roles []string
+	// Storage holds the slots declared in the :storage section
+	Storage []QType
+	// Attributes holds the metadata attributes such as version, author, license, description and custom x- keys
+	Attributes map[string]string
+	// Constants are the named constants declared with :const= by the contract and the interfaces it implements
+	Constants []QConst
+}
+
+// QConst is a named integer constant, which can also be used as the length of a fixed size array
+type QConst struct {
+	Name  string
+	Type  string
+	Value string
+}
+
+// GenDefineC generates a #define of the constant, named after the contract so that it can't clash with macros of
+// system headers or other contracts. Every generated file carries the same definition, which C allows to repeat
+func (c QConst) GenDefineC(contractName string) string {
+	suffix := ""
+	switch c.Type {
+	case "uint64":
+		suffix = "ULL"
+	case "int64":
+		suffix = "LL"
+	}
+	return "#define " + contractName + "_" + c.Name + " ((" + c.Type + "_t)" + c.Value + suffix + ")"
+}
+
+// GenFileHeaderC generates a comment listing the contract's interfaces and metadata attributes for the top of a generated file.
+// Well known attributes come first, followed by custom attributes in alphabetical order
+func (q QInterfaceBuilder) GenFileHeaderC() string {
+	lines := q.fileHeaderLines()
+	if len(lines) == 0 {
+		return ""
+	}
+	comment := []string{"/*"}
+	for _, line := range lines {
+		comment = append(comment, " * "+line)
+	}
+	comment = append(comment, " */")
+	return strings.Join(comment, "\n") + "\n"
+}
+
+// fileHeaderLines returns the lines of the file header comment without any comment markers,
+// or nothing when the contract has no interfaces or attributes to list
+func (q QInterfaceBuilder) fileHeaderLines() []string {
+	if len(q.Attributes) == 0 && len(q.Implements) == 0 {
+		return nil
+	}
+	var keys []string
+	for _, key := range []string{"version", "author", "license", "description"} {
+		if _, exists := q.Attributes[key]; exists {
+			keys = append(keys, key)
+		}
+	}
+	var custom []string
+	for key := range q.Attributes {
+		if strings.HasPrefix(key, "x-") {
+			custom = append(custom, key)
+		}
+	}
+	sort.Strings(custom)
+	lines := []string{q.ContractName}
+	if len(q.Implements) > 0 {
+		lines = append(lines, "implements: "+strings.Join(q.Implements, ", "))
+	}
+	for _, key := range append(keys, custom...) {
+		lines = append(lines, key+": "+q.Attributes[key])
+	}
+	return lines
+}
+
+// UsesReentrancyGuard reports whether any function of the contract is marked nonreentrant
+func (q QInterfaceBuilder) UsesReentrancyGuard() bool {
+	for _, function := range q.Functions {
+		if function.NonReentrant {
+			return true
+		}
+	}
+	return false
 }
 
 // QFunc is a function as defined in the SimpleABI protocol
@@ -20,6 +108,48 @@ type QFunc struct {
 	Inputs   []QType
 	Outputs  []QType
 	Payable  bool
+	// OnlyRoles restricts the function to senders holding one of these roles
+	OnlyRoles []string
+	// NonReentrant wraps the implementation call in the contract's storage backed reentrancy lock
+	NonReentrant bool
+	// Doc is the "##" doc comment preceding the function
+	Doc string
+	// Overloaded is set when the contract declares several functions with this name, their C symbols are then mangled
+	Overloaded bool
+	// Annotations holds the @key(value) annotations written after the function name, such as gas
+	Annotations map[string]string
+}
+
+// CName is the name used for the function's C symbols and ID_ defines. It is the function name unless the
+// function is overloaded, in which case the input types are mangled into it, e.g. transfer__u64_addr
+func (q QFunc) CName() string {
+	if q.Overloaded {
+		return q.MangledName()
+	}
+	return q.FuncName
+}
+
+// MangledName appends the abbreviated input types to the function name, so "to:uniaddress amount:uint64 transfer:fn"
+// becomes transfer__addr_u64. Dynamic arrays are suffixed with "arr" and fixed size arrays with their length,
+// e.g. u8arr and u8x32. Caller context parameters are left out
+func (q QFunc) MangledName() string {
+	var types []string
+	for _, input := range q.encodedInputs() {
+		typ := strings.Replace(strings.Replace(getBaseType(input.Type), "uint", "u", 1), "int", "i", 1)
+		if input.Type == "uniaddress" || getBaseType(input.Type) == "uniaddress" {
+			typ = "addr"
+		}
+		if isArray(input.Type) {
+			typ += "arr"
+		} else if isFixedArray(input.Type) {
+			typ += "x" + strconv.Itoa(input.Length)
+		}
+		types = append(types, typ)
+	}
+	if len(types) == 0 {
+		types = append(types, "void")
+	}
+	return q.FuncName + "__" + strings.Join(types, "_")
 }
 
 // QType is a helper type for better code generation of inputs and outputs.
@@ -27,6 +157,12 @@ type QFunc struct {
 type QType struct {
 	TypeName string
 	Type     string
+	// Doc is the "##" doc comment describing this input, output or storage slot
+	Doc string
+	// Length is the resolved number of elements of a fixed size array such as uint8[KEY_LEN]
+	Length int
+	// Annotations holds the @key(value) annotations written after the parameter, such as maxlen
+	Annotations map[string]string
 }
 
 // GenFuncSignatureC generates a function signature to be used in templating. Takes a contract name to complete the function signature
@@ -39,8 +175,15 @@ func (q QFunc) GenFuncSignatureC(contractName string, isEncoding bool) string {
 		sigInParens = append(sigInParens, []string{"const UniversalAddress *__address", "const QtumCallOptions* __options"}...)
 	}
 	for _, input := range q.Inputs {
-		if isArray(input.Type) {
-			sigInParens = append(sigInParens, "const " + getBaseType(input.Type)+"_t* "+input.TypeName)
+		if isContextType(input.Type) {
+			// context values are filled in by the dispatcher, callers never pass them
+			if !isEncoding {
+				sigInParens = append(sigInParens, getContextTypeC(input.Type)+" "+input.TypeName)
+			}
+		} else if isFixedArray(input.Type) {
+			sigInParens = append(sigInParens, "const "+getCBaseType(getBaseType(input.Type))+"* "+input.TypeName)
+		} else if isArray(input.Type) {
+			sigInParens = append(sigInParens, "const "+getCBaseType(getBaseType(input.Type))+"* "+input.TypeName)
 			sigInParens = append(sigInParens, "size_t "+input.TypeName+"_sz")
 		} else if input.Type == "uniaddress" {
 			sigInParens = append(sigInParens, "const UniversalAddressABI* "+input.TypeName)
@@ -50,8 +193,10 @@ func (q QFunc) GenFuncSignatureC(contractName string, isEncoding bool) string {
 	}
 
 	for _, output := range q.Outputs {
-		if isArray(output.Type) {
-			sigInParens = append(sigInParens, getBaseType(output.Type)+"_t** "+output.TypeName)
+		if isFixedArray(output.Type) {
+			sigInParens = append(sigInParens, getCBaseType(getBaseType(output.Type))+"* "+output.TypeName)
+		} else if isArray(output.Type) {
+			sigInParens = append(sigInParens, getCBaseType(getBaseType(output.Type))+"** "+output.TypeName)
 			sigInParens = append(sigInParens, "size_t* "+output.TypeName+"_sz")
 		} else if output.Type == "uniaddress" {
 			sigInParens = append(sigInParens, "UniversalAddressABI** "+output.TypeName)
@@ -60,13 +205,72 @@ func (q QFunc) GenFuncSignatureC(contractName string, isEncoding bool) string {
 		}
 	}
 	if isEncoding {
-		return contractName + "_" + q.FuncName + "(" + strings.Join(sigInParens, ", ") + ")"
+		return contractName + "_" + q.CName() + "(" + strings.Join(sigInParens, ", ") + ")"
 	}
 	
-	return contractName + "_" + q.FuncName + "_dispatch" + "(" + strings.Join(sigInParens, ", ") + ")"
+	return contractName + "_" + q.CName() + "_dispatch" + "(" + strings.Join(sigInParens, ", ") + ")"
 	
 }
 
+// GenDocC generates a Doxygen comment for the function signature generated by GenFuncSignatureC with the same isEncoding.
+// It returns an empty string when the function has no documentation, otherwise the comment ends with a newline
+func (q QFunc) GenDocC(isEncoding bool) string {
+	var lines []string
+	if q.Doc != "" {
+		lines = append(lines, strings.Split(q.Doc, "\n")...)
+	}
+	if deprecated, ok := q.Annotations["deprecated"]; ok {
+		lines = append(lines, strings.TrimSpace("@deprecated "+deprecated))
+	}
+	for _, input := range q.Inputs {
+		if doc := input.paramDoc(); doc != "" && !(isEncoding && isContextType(input.Type)) {
+			lines = append(lines, "@param "+input.TypeName+" "+doc)
+		}
+	}
+	for _, output := range q.Outputs {
+		if doc := output.paramDoc(); doc != "" {
+			lines = append(lines, "@param[out] "+output.TypeName+" "+doc)
+		}
+	}
+	return genDocCommentC(lines)
+}
+
+// paramDoc is the documentation of a parameter including the notes its annotations call for
+func (typ QType) paramDoc() string {
+	doc := typ.Doc
+	if maxlen, ok := typ.Annotations["maxlen"]; ok {
+		doc = strings.TrimSpace(doc + " (at most " + maxlen + " elements)")
+	}
+	if deprecated, ok := typ.Annotations["deprecated"]; ok {
+		note := "deprecated"
+		if deprecated != "" {
+			note += ": " + deprecated
+		}
+		doc = strings.TrimSpace(doc + " (" + note + ")")
+	}
+	return doc
+}
+
+// GenDocC generates a Doxygen comment for a storage slot's accessors, see QFunc.GenDocC
+func (typ QType) GenDocC() string {
+	if typ.Doc == "" {
+		return ""
+	}
+	return genDocCommentC(strings.Split(typ.Doc, "\n"))
+}
+
+func genDocCommentC(lines []string) string {
+	if len(lines) == 0 {
+		return ""
+	}
+	comment := []string{"/**"}
+	for _, line := range lines {
+		comment = append(comment, strings.TrimRight(" * "+line, " "))
+	}
+	comment = append(comment, " */")
+	return strings.Join(comment, "\n") + "\n"
+}
+
 func (q QFunc) generateFuncCallSignatureC(contractName string) string {
 	var sig []string
 	for _, input := range q.Inputs {
@@ -76,25 +280,55 @@ func (q QFunc) generateFuncCallSignatureC(contractName string) string {
 		}
 	}
 	for _, output := range q.Outputs {
+		if isFixedArray(output.Type) {
+			// fixed size arrays are declared as C arrays, which already decay to a pointer
+			sig = append(sig, output.TypeName)
+			continue
+		}
 		sig = append(sig, "&" + output.TypeName)
 		if(isArray(output.Type)){
 			sig = append(sig, "&" + output.TypeName + "_sz");
 		}
 	}
 	//this is only used for decoding, so add _dispatch suffix
-	return contractName + "_" + q.FuncName + "_dispatch" + "(" + strings.Join(sig, ", ") + ");"
+	return contractName + "_" + q.CName() + "_dispatch" + "(" + strings.Join(sig, ", ") + ");"
+}
+
+// CanonicalSignature describes the call interface of the function in .abi syntax, e.g. "uint64 uniaddress transfer:fn:payable -> uint8".
+// Parameter names, caller context parameters and modifiers that only affect the implementation are left out,
+// so two functions with the same canonical signature can be called the same way
+func (q QFunc) CanonicalSignature() string {
+	var sig []string
+	for _, input := range q.encodedInputs() {
+		sig = append(sig, input.canonicalType())
+	}
+	if len(sig) == 0 {
+		sig = append(sig, "void")
+	}
+	name := q.FuncName + ":fn"
+	if q.Payable {
+		name += ":payable"
+	}
+	sig = append(sig, name, "->")
+	if len(q.Outputs) == 0 {
+		sig = append(sig, "void")
+	}
+	for _, output := range q.Outputs {
+		sig = append(sig, output.canonicalType())
+	}
+	return strings.Join(sig, " ")
 }
 
 // GenHashedFuncIdentifier generates a hashed function identifier from a function signature
 func (q QFunc) GenHashedFuncIdentifier(contractName string) string {
 	var toHashArr []string
-	for _, input := range q.Inputs {
-		toHashArr = append(toHashArr, input.Type)
+	for _, input := range q.encodedInputs() {
+		toHashArr = append(toHashArr, input.canonicalType())
 	}
 	toHashArr = append(toHashArr, contractName+"_"+q.FuncName)
 	toHashArr = append(toHashArr, "->")
 	for _, output := range q.Outputs {
-		toHashArr = append(toHashArr, output.Type)
+		toHashArr = append(toHashArr, output.canonicalType())
 	}
 	toHash := []byte(strings.Join(toHashArr, " "))
 	h := sha256.New()
@@ -103,7 +337,8 @@ func (q QFunc) GenHashedFuncIdentifier(contractName string) string {
 	return fmt.Sprintf("0x%x", funcHash[:4])
 }
 
-// GenFuncCallQtum creates a function body for a Qtum Function Call in C
+// GenFuncCallQtum creates a function body for a Qtum Function Call in C. Inputs go on and outputs come off
+// the stack last to first, see the stack package
 func (q QFunc) GenFuncCallQtum(contractName string) string {
 	var statement []string
 	if !q.Payable {
@@ -112,50 +347,50 @@ func (q QFunc) GenFuncCallQtum(contractName string) string {
 		statement = append(statement, "}")
 	}
 	// push inputs onto stack
-	for i, input := range q.Inputs {
-		var pushStatement string
-		if isArray(input.Type) {
-			pushStatement = getQtumPushStatement(input.Type) + "(" + input.TypeName + ", " + input.TypeName + "_sz);"
-		} else {
-			pushStatement = getQtumPushStatement(input.Type) + "(" + input.TypeName + ");"
-		}
-
-		if i == 0 {
-			statement = append(statement, "\t"+pushStatement)
+	inputs := q.encodedInputs()
+	for i := len(inputs) - 1; i >= 0; i-- {
+		input := inputs[i]
+		if isFixedArray(input.Type) {
+			statement = append(statement, getQtumPushStatement(input.Type)+"("+input.TypeName+", "+getArrayLengthC(contractName, input.Type)+" * sizeof(*"+input.TypeName+"));")
+		} else if isArray(input.Type) {
+			statement = append(statement, getQtumPushStatement(input.Type)+"("+input.TypeName+", "+input.TypeName+"_sz * sizeof(*"+input.TypeName+"));")
+		} else if input.Type == "uniaddress" {
+			statement = append(statement, getQtumPushStatement(input.Type)+"("+input.TypeName+", sizeof(UniversalAddressABI));")
 		} else {
-			statement = append(statement, pushStatement)
+			statement = append(statement, getQtumPushStatement(input.Type)+"("+input.TypeName+");")
 		}
 	}
-	statement = append(statement, getQtumPushStatement("int32")+"(ID_"+contractName+"_"+q.FuncName+");")
+	statement = append(statement, getQtumPushStatement("int32")+"(ID_"+contractName+"_"+q.CName()+");")
 	statement = append(statement, "QtumCallResult r = qtumCall(__address, __options);")
 	statement = append(statement, "if(r.error == QTUM_CALL_SUCCESS){")
-
-	for _, output := range q.Outputs {
-		statement = append(statement, output.generateFuncCallBody()...)
+	for i := len(q.Outputs) - 1; i >= 0; i-- {
+		statement = append(statement, q.Outputs[i].generateFuncCallBody(contractName)...)
 	}
 	statement = append(statement, "}")
 	statement = append(statement, "return r;")
 	return strings.Join(statement, "\n\t")
 }
 
-func (typ QType) generateFuncCallBody() []string {
+func (typ QType) generateFuncCallBody(contractName string) []string {
 	switch {
+	case isFixedArray(typ.Type):
+		return []string{fmt.Sprintf("\t%v(%v, %v * sizeof(*%v));", getQtumPopStatement(typ.Type), typ.TypeName, getArrayLengthC(contractName, typ.Type), typ.TypeName)}
 	case isArray(typ.Type):
 		return []string{
 			fmt.Sprintf("\t*%v_sz = qtumPeekSize();", typ.TypeName),
-			fmt.Sprintf("\t*%v = malloc(*%v_sz * sizeof(**%v));", typ.TypeName, typ.TypeName, typ.TypeName),
-			fmt.Sprintf("\t%v(*%v, *%v_sz * sizeof(**%v));", getQtumPopStatement(typ.Type), typ.TypeName, typ.TypeName, typ.TypeName),
+			fmt.Sprintf("\t*%v = malloc(*%v_sz);", typ.TypeName, typ.TypeName),
+			fmt.Sprintf("\t%v(*%v, *%v_sz);", getQtumPopStatement(typ.Type), typ.TypeName, typ.TypeName),
 			fmt.Sprintf("\t*%v_sz /= sizeof(**%v);", typ.TypeName, typ.TypeName),
 		}
 	case typ.Type == "uniaddress":
 		return []string{
-			fmt.Sprintf("\tif(%v == NULL){", typ.TypeName),
-			fmt.Sprintf("\t\t%v = malloc(sizeof(UniversalAddressABI));", typ.TypeName),
+			fmt.Sprintf("\tif(*%v == NULL){", typ.TypeName),
+			fmt.Sprintf("\t\t*%v = malloc(sizeof(UniversalAddressABI));", typ.TypeName),
 			"\t}",
-			fmt.Sprintf("\tif(%v == NULL){", typ.TypeName),
+			fmt.Sprintf("\tif(*%v == NULL){", typ.TypeName),
 			"\t\tqtumErase();",
 			"\t}else{",
-			fmt.Sprintf("\t\tqtumPop(%v, sizeof(UniversalAddressABI));", typ.TypeName),
+			fmt.Sprintf("\t\tqtumPop(*%v, sizeof(UniversalAddressABI));", typ.TypeName),
 			"\t}",
 		}
 	default:
@@ -171,12 +406,23 @@ func (q QFunc) GenDispatchCodeC(contractName string) string {
 		statement = append(statement, "\tqtumError(\"nonpayable function\");")
 		statement = append(statement, "}")
 	}
+	statement = append(statement, q.genRoleGuardC(contractName)...)
 	// Pop off inputs
 	for _, input := range q.Inputs {
 		popStatement := getQtumPopStatement(input.Type)
-		if isArray(input.Type) {
-			statement = append(statement, getBaseType(input.Type)+"_t* "+input.TypeName+";")
+		if isContextType(input.Type) {
+			statement = append(statement, getContextTypeC(input.Type)+" "+input.TypeName+" = "+getContextValueC(input.Type)+";")
+		} else if isFixedArray(input.Type) {
+			statement = append(statement, getCBaseType(getBaseType(input.Type))+" "+input.TypeName+"["+getArrayLengthC(contractName, input.Type)+"];")
+			statement = append(statement, popStatement+"("+input.TypeName+", sizeof("+input.TypeName+"));")
+		} else if isArray(input.Type) {
+			statement = append(statement, getCBaseType(getBaseType(input.Type))+"* "+input.TypeName+";")
 			statement = append(statement, "size_t "+input.TypeName+"_sz = qtumPeekSize();")
+			if maxlen, ok := input.Annotations["maxlen"]; ok {
+				statement = append(statement, "if("+input.TypeName+"_sz > "+maxlen+" * sizeof(*"+input.TypeName+")) {")
+				statement = append(statement, "\tqtumError(\""+input.TypeName+" is longer than "+maxlen+" elements\");")
+				statement = append(statement, "}")
+			}
 			statement = append(statement, input.TypeName+" = malloc("+input.TypeName+"_sz);")
 			statement = append(statement, popStatement+"("+input.TypeName+", "+input.TypeName+"_sz);")
 		} else if input.Type == "uniaddress" {
@@ -188,8 +434,10 @@ func (q QFunc) GenDispatchCodeC(contractName string) string {
 	}
 	// Declare types with assigned null values
 	for _, output := range q.Outputs {
-		if isArray(output.Type) {
-			statement = append(statement, output.Type+"_t* "+output.TypeName+" = NULL;")
+		if isFixedArray(output.Type) {
+			statement = append(statement, getCBaseType(getBaseType(output.Type))+" "+output.TypeName+"["+getArrayLengthC(contractName, output.Type)+"] = {0};")
+		} else if isArray(output.Type) {
+			statement = append(statement, getCBaseType(getBaseType(output.Type))+"* "+output.TypeName+" = NULL;")
 			statement = append(statement, "size_t "+output.TypeName+"_sz;")
 		} else if output.Type == "uniaddress" {
 			statement = append(statement, "UniversalAddressABI* "+output.TypeName+" = NULL;")
@@ -197,12 +445,20 @@ func (q QFunc) GenDispatchCodeC(contractName string) string {
 			statement = append(statement, output.Type+"_t "+output.TypeName+" = 0;")
 		}
 	}
-	// append function call
+	// append function call, holding the reentrancy lock for as long as the implementation runs
+	if q.NonReentrant {
+		statement = append(statement, contractName+"_nonreentrant_enter();")
+	}
 	statement = append(statement, q.generateFuncCallSignatureC(contractName))
+	if q.NonReentrant {
+		statement = append(statement, contractName+"_nonreentrant_exit();")
+	}
 	// append push statements for outputs
 	for _, output := range q.Outputs {
 		pushStatement := getQtumPushStatement(output.Type)
-		if isArray(output.Type) {
+		if isFixedArray(output.Type) {
+			statement = append(statement, pushStatement+"("+output.TypeName+", sizeof("+output.TypeName+"));")
+		} else if isArray(output.Type) {
 			statement = append(statement, pushStatement+"("+output.TypeName+", "+output.TypeName+"_sz * sizeof(*"+output.TypeName+"));")
 		} else if output.Type == "uniaddress" {
 			statement = append(statement, pushStatement+"("+output.TypeName+", sizeof(UniversalAddressABI));")
@@ -214,6 +470,36 @@ func (q QFunc) GenDispatchCodeC(contractName string) string {
 	return strings.Join(statement, "\n\t\t")
 }
 
+// genRoleGuardC checks the sender against the accessors of every role allowed by only(...)
+func (q QFunc) genRoleGuardC(contractName string) []string {
+	if len(q.OnlyRoles) == 0 {
+		return nil
+	}
+	var statement []string
+	var checks []string
+	for _, role := range q.OnlyRoles {
+		statement = append(statement, "UniversalAddressABI __role_"+role+";")
+		statement = append(statement, contractName+"_role_"+role+"(&__role_"+role+");")
+		checks = append(checks, "memcmp(&__role_"+role+", &qtumExec->sender, sizeof(UniversalAddressABI)) != 0")
+	}
+	statement = append(statement, "if("+strings.Join(checks, " && ")+") {")
+	statement = append(statement, "\tqtumError(\"unauthorized: only "+strings.Join(q.OnlyRoles, " or ")+"\");")
+	statement = append(statement, "}")
+	return statement
+}
+
+// encodedInputs returns the inputs that travel on the call stack, leaving out
+// caller context parameters such as @sender which the dispatcher fills in itself
+func (q QFunc) encodedInputs() []QType {
+	var inputs []QType
+	for _, input := range q.Inputs {
+		if !isContextType(input.Type) {
+			inputs = append(inputs, input)
+		}
+	}
+	return inputs
+}
+
 func getQtumPushStatement(typ string) string {
 	switch typ {
 	case "uint8", "int8":
@@ -246,10 +532,87 @@ func getQtumPopStatement(typ string) string {
 	}
 }
 
+func getContextTypeC(typ string) string {
+	switch typ {
+	case "@sender", "@origin":
+		return "const UniversalAddressABI*"
+	case "@value":
+		return "uint64_t"
+	default:
+		return ""
+	}
+}
+
+func getContextValueC(typ string) string {
+	switch typ {
+	case "@sender":
+		return "&qtumExec->sender"
+	case "@origin":
+		return "&qtumExec->origin"
+	case "@value":
+		return "qtumExec->valueSent"
+	default:
+		return ""
+	}
+}
+
+func isContextType(typ string) bool {
+	switch typ {
+	case "@sender", "@value", "@origin":
+		return true
+	default:
+		return false
+	}
+}
+
 func isArray(typ string) bool {
 	return strings.HasSuffix(typ, "[]")
 }
 
+// getCBaseType is the C type of a single element of typ, e.g. uint8_t or UniversalAddressABI
+func getCBaseType(typ string) string {
+	if typ == "uniaddress" {
+		return "UniversalAddressABI"
+	}
+	return typ + "_t"
+}
+
+// isIntegerType reports whether typ is one of the integer types, which travel on the call stack by value
+func isIntegerType(typ string) bool {
+	return !isArray(typ) && !isFixedArray(typ) && typ != "uniaddress"
+}
+
+// isFixedArray reports whether typ is a fixed size array such as uint8[32] or uint8[KEY_LEN]
+func isFixedArray(typ string) bool {
+	return strings.HasSuffix(typ, "]") && !isArray(typ)
+}
+
+// getArrayLength returns the length of a fixed size array as written, either a number or a constant name
+func getArrayLength(typ string) string {
+	return typ[strings.Index(typ, "[")+1 : len(typ)-1]
+}
+
+// getArrayLengthC is the length of a fixed size array in C, constant names refer to the macros of GenDefineC
+func getArrayLengthC(contractName string, typ string) string {
+	length := getArrayLength(typ)
+	if _, err := strconv.Atoi(length); err == nil {
+		return length
+	}
+	return contractName + "_" + length
+}
+
+// canonicalType is the type as it is hashed into function identifiers, fixed size arrays use
+// their resolved length so renaming a constant does not change the identifier
+func (typ QType) canonicalType() string {
+	if isFixedArray(typ.Type) {
+		return getBaseType(typ.Type) + "[" + strconv.Itoa(typ.Length) + "]"
+	}
+	return typ.Type
+}
+
 func getBaseType(typ string) string {
-	return strings.TrimSuffix(typ, "[]")
+	if i := strings.Index(typ, "["); i != -1 {
+		return typ[:i]
+	}
+	return typ
 }
@@ -0,0 +1,302 @@
+package definitions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// goKeywords can't be used as names in Go, goName appends an underscore to them
+var goKeywords = map[string]bool{
+	"break": true, "case": true, "chan": true, "const": true, "continue": true, "default": true, "defer": true,
+	"else": true, "fallthrough": true, "for": true, "func": true, "go": true, "goto": true, "if": true,
+	"import": true, "interface": true, "map": true, "package": true, "range": true, "return": true,
+	"select": true, "struct": true, "switch": true, "type": true, "var": true,
+	// predeclared names the bindings use, which a parameter would shadow
+	"error": true, "nil": true, "true": true, "false": true, "byte": true, "uint8": true, "uint16": true,
+	"uint32": true, "uint64": true, "int8": true, "int16": true, "int32": true, "int64": true,
+}
+
+// goName is name as a Go identifier
+func goName(name string) string {
+	if goKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// GoPackage is the name of the package holding the contract's Go bindings
+func (q QInterfaceBuilder) GoPackage() string {
+	return goName(strings.ToLower(q.ContractName))
+}
+
+// GoTypeName is the name of the type calling the contract in its Go bindings
+func (q QInterfaceBuilder) GoTypeName() string {
+	return goName(q.ContractName)
+}
+
+// GenPackageGo generates the package clause of the Go bindings. It fails when two names of the contract become
+// the same Go name, such as functions transfer and Transfer, which would both have a Transfer method
+func (q QInterfaceBuilder) GenPackageGo() (string, error) {
+	// package level names and the methods of the contract type are separate scopes
+	declared := map[string]string{
+		"Functions":            "the Functions variable",
+		q.GoTypeName():         "the contract type",
+		"New" + q.ContractName: "the New" + q.ContractName + " function",
+	}
+	methods := map[string]string{}
+	declare := func(names map[string]string, name string, what string) error {
+		if other, exists := names[name]; exists {
+			return fmt.Errorf("%v and %v both become %v in the Go bindings, rename one of them", other, what, name)
+		}
+		names[name] = what
+		return nil
+	}
+	for _, c := range q.Constants {
+		if err := declare(declared, goName(c.Name), "constant "+c.Name); err != nil {
+			return "", err
+		}
+	}
+	for _, function := range q.Functions {
+		what := "function " + function.FuncName
+		if err := declare(methods, function.GoName(), what); err != nil {
+			return "", err
+		}
+		for _, name := range []string{function.GoName() + "Selector", "Encode" + function.GoName(), "Decode" + function.GoName()} {
+			if err := declare(declared, name, what); err != nil {
+				return "", err
+			}
+		}
+	}
+	return "package " + q.GoPackage(), nil
+}
+
+// GenFileHeaderGo generates the file header comment of GenFileHeaderC as Go line comments
+func (q QInterfaceBuilder) GenFileHeaderGo() string {
+	var comment []string
+	for _, line := range q.fileHeaderLines() {
+		comment = append(comment, "// "+line)
+	}
+	if len(comment) == 0 {
+		return ""
+	}
+	return strings.Join(comment, "\n") + "\n"
+}
+
+// GenConstGo generates a constant declaration for a const block
+func (c QConst) GenConstGo() string {
+	return goName(c.Name) + " " + c.Type + " = " + c.Value
+}
+
+// GoName is the exported Go name of the function
+func (q QFunc) GoName() string {
+	name := q.CName()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// GenFunctionGo generates the entry of the function in the Functions variable of the Go bindings
+func (q QFunc) GenFunctionGo(contractName string) string {
+	fields := []string{
+		"Name: " + strconv.Quote(q.FuncName),
+		"Signature: " + strconv.Quote(q.CanonicalSignature()),
+		"Selector: " + q.GoName() + "Selector",
+		"Payable: " + strconv.FormatBool(q.Payable),
+	}
+	if gas, ok := q.Annotations["gas"]; ok {
+		fields = append(fields, "Gas: "+gas)
+	}
+	return "{" + strings.Join(fields, ", ") + "},"
+}
+
+// GenDocGo generates the doc comment of the method calling the function
+func (q QFunc) GenDocGo() string {
+	lines := []string{q.GoName() + " calls " + q.FuncName + " on the contract."}
+	if q.Doc != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(q.Doc, "\n")...)
+	}
+	var params []string
+	for _, param := range append(q.encodedInputs(), q.Outputs...) {
+		if doc := param.paramDoc(); doc != "" {
+			params = append(params, "  - "+goName(param.TypeName)+": "+doc)
+		}
+	}
+	if len(params) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, params...)
+	}
+	if deprecated, ok := q.Annotations["deprecated"]; ok {
+		lines = append(lines, "", strings.TrimSpace("Deprecated: "+deprecated))
+	}
+	var comment []string
+	for _, line := range lines {
+		comment = append(comment, strings.TrimRight("// "+line, " "))
+	}
+	return strings.Join(comment, "\n") + "\n"
+}
+
+// GenMethodGo generates the method calling the function through the bindings' Caller, payable functions
+// take the value sent along as their first parameter
+func (q QFunc) GenMethodGo(contractName string) string {
+	var params []string
+	var args []string
+	value := "0"
+	if q.Payable {
+		params = append(params, "__value uint64")
+		value = "__value"
+	}
+	for _, input := range q.encodedInputs() {
+		params = append(params, goName(input.TypeName)+" "+getGoType(input))
+		args = append(args, goName(input.TypeName))
+	}
+	options := "qtumstack.CallOptions{Value: " + value + ", GasLimit: " + q.goGas() + "}"
+	lines := []string{
+		"func (__c *" + contractName + ") " + q.GoName() + "(" + strings.Join(params, ", ") + ") " + q.goResults() + " {",
+		"\t__data, __err := __c.caller.Call(Encode" + q.GoName() + "(" + strings.Join(args, ", ") + "), " + options + ")",
+		"\tif __err != nil {",
+		"\t\treturn " + q.goErrorReturn("__err"),
+		"\t}",
+		"\treturn Decode" + q.GoName() + "(__data)",
+		"}",
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GenEncodeGo generates the function returning the call data of a call to the function, in the order
+// the stack package documents
+func (q QFunc) GenEncodeGo() string {
+	var params []string
+	for _, input := range q.encodedInputs() {
+		params = append(params, goName(input.TypeName)+" "+getGoType(input))
+	}
+	lines := []string{
+		"// Encode" + q.GoName() + " returns the call data of a call to " + q.FuncName,
+		"func Encode" + q.GoName() + "(" + strings.Join(params, ", ") + ") []byte {",
+		"\tvar __s qtumstack.Stack",
+	}
+	inputs := q.encodedInputs()
+	for i := len(inputs) - 1; i >= 0; i-- {
+		lines = append(lines, "\t"+getGoPushStatement(inputs[i]))
+	}
+	lines = append(lines, "\tqtumstack.PushInteger(&__s, "+q.GoName()+"Selector)", "\treturn __s.Encode()", "}")
+	return strings.Join(lines, "\n")
+}
+
+// GenDecodeGo generates the function decoding the outputs of the function from the data a call returned
+func (q QFunc) GenDecodeGo() string {
+	lines := []string{
+		"// Decode" + q.GoName() + " decodes the outputs of " + q.FuncName + " from the data a call returned",
+		"func Decode" + q.GoName() + "(__data []byte) " + q.goResults() + " {",
+	}
+	if len(q.Outputs) == 0 {
+		lines = append(lines, "\t_, __err := qtumstack.Decode(__data)", "\treturn __err", "}")
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines,
+		"\t__s, __err := qtumstack.Decode(__data)",
+		"\tif __err != nil {",
+		"\t\treturn "+q.goErrorReturn("__err"),
+		"\t}",
+	)
+	for _, output := range q.Outputs {
+		lines = append(lines, "\tvar "+goName(output.TypeName)+" "+getGoType(output))
+	}
+	for i := len(q.Outputs) - 1; i >= 0; i-- {
+		lines = append(lines, "\tif "+getGoPopStatement(q.Outputs[i])+"; __err != nil {", "\t\treturn "+q.goErrorReturn("__err"), "\t}")
+	}
+	var names []string
+	for _, output := range q.Outputs {
+		names = append(names, goName(output.TypeName))
+	}
+	lines = append(lines, "\treturn "+strings.Join(append(names, "nil"), ", "), "}")
+	return strings.Join(lines, "\n")
+}
+
+// goResults is the result list of the functions returning the outputs and an error
+func (q QFunc) goResults() string {
+	if len(q.Outputs) == 0 {
+		return "error"
+	}
+	var types []string
+	for _, output := range q.Outputs {
+		types = append(types, getGoType(output))
+	}
+	return "(" + strings.Join(append(types, "error"), ", ") + ")"
+}
+
+// goErrorReturn returns zero values for the outputs followed by err
+func (q QFunc) goErrorReturn(err string) string {
+	var values []string
+	for _, output := range q.Outputs {
+		values = append(values, getGoZeroValue(output))
+	}
+	return strings.Join(append(values, err), ", ")
+}
+
+func (q QFunc) goGas() string {
+	if gas, ok := q.Annotations["gas"]; ok {
+		return gas
+	}
+	return "0"
+}
+
+// getGoType is the Go type of an input or output
+func getGoType(typ QType) string {
+	switch {
+	case isFixedArray(typ.Type):
+		return "[" + strconv.Itoa(typ.Length) + "]" + getGoBaseType(getBaseType(typ.Type))
+	case isArray(typ.Type):
+		return "[]" + getGoBaseType(getBaseType(typ.Type))
+	default:
+		return getGoBaseType(typ.Type)
+	}
+}
+
+func getGoBaseType(typ string) string {
+	if typ == "uniaddress" {
+		return "qtumstack.Address"
+	}
+	return typ
+}
+
+func getGoZeroValue(typ QType) string {
+	switch {
+	case isFixedArray(typ.Type):
+		return getGoType(typ) + "{}"
+	case isArray(typ.Type):
+		return "nil"
+	case typ.Type == "uniaddress":
+		return "qtumstack.Address{}"
+	default:
+		return "0"
+	}
+}
+
+func getGoPushStatement(typ QType) string {
+	name := goName(typ.TypeName)
+	switch {
+	case isFixedArray(typ.Type):
+		return "qtumstack.PushArray(&__s, " + name + "[:])"
+	case isArray(typ.Type):
+		return "qtumstack.PushArray(&__s, " + name + ")"
+	case typ.Type == "uniaddress":
+		return "qtumstack.PushAddress(&__s, " + name + ")"
+	default:
+		return "qtumstack.PushInteger(&__s, " + name + ")"
+	}
+}
+
+// getGoPopStatement pops typ into the variable of the same name, setting __err
+func getGoPopStatement(typ QType) string {
+	name := goName(typ.TypeName)
+	switch {
+	case isFixedArray(typ.Type):
+		return "__err = qtumstack.PopFixedArray(__s, &" + name + ")"
+	case isArray(typ.Type):
+		return "__err = qtumstack.PopArray(__s, &" + name + ")"
+	case typ.Type == "uniaddress":
+		return name + ", __err = qtumstack.PopAddress(__s)"
+	default:
+		return "__err = qtumstack.PopInteger(__s, &" + name + ")"
+	}
+}
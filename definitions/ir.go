@@ -0,0 +1,135 @@
+package definitions
+
+// IRVersion is the version of the JSON IR of a contract handed to generator plugins. Adding fields keeps
+// the version, it is only bumped when a field is removed or changes meaning
+const IRVersion = 1
+
+// IRContract is the fully resolved contract as serialized for generator plugins: interfaces are merged in,
+// constants are resolved and overloaded functions carry their mangled names and selectors
+type IRContract struct {
+	Name            string            `json:"name"`
+	Attributes      map[string]string `json:"attributes"`
+	Implements      []string          `json:"implements"`
+	Roles           []string          `json:"roles"`
+	Constants       []IRConst         `json:"constants"`
+	Storage         []IRType          `json:"storage"`
+	Functions       []IRFunction      `json:"functions"`
+	ReentrancyGuard bool              `json:"reentrancyGuard"`
+}
+
+// IRConst is a constant declared with :const=, its value is written as a decimal integer
+type IRConst struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value string `json:"value"`
+}
+
+// IRFunction is a function of the contract
+type IRFunction struct {
+	Name string `json:"name"`
+	// CName is the name of the function's C symbols, mangled when the function is overloaded
+	CName string `json:"cName"`
+	// Selector is the function ID the dispatcher switches on, e.g. 0x5a41ae21
+	Selector string `json:"selector"`
+	// Signature is the canonical signature in .abi syntax, see QFunc.CanonicalSignature
+	Signature    string            `json:"signature"`
+	Payable      bool              `json:"payable"`
+	NonReentrant bool              `json:"nonReentrant"`
+	OnlyRoles    []string          `json:"onlyRoles"`
+	Overloaded   bool              `json:"overloaded"`
+	Doc          string            `json:"doc"`
+	Annotations  map[string]string `json:"annotations"`
+	// Inputs are all the inputs in declared order, including caller context parameters which have Context set
+	Inputs  []IRType `json:"inputs"`
+	Outputs []IRType `json:"outputs"`
+}
+
+// IRType is an input, output or storage slot
+type IRType struct {
+	Name string `json:"name"`
+	// Type is the type as written, e.g. uint8[KEY_LEN], uniaddress[], @sender or map<uniaddress,uint64>
+	Type string `json:"type"`
+	// BaseType is the type of a single array element, or Type for anything else
+	BaseType string `json:"baseType"`
+	// Array is set for dynamic arrays, which are pushed as a single item holding all the elements
+	Array bool `json:"array"`
+	// Length is the resolved length of a fixed size array and 0 for every other type
+	Length int `json:"length"`
+	// Context is set for caller context parameters, which the dispatcher fills in instead of popping them
+	Context bool `json:"context"`
+	// KeyType and ValueType are the types of a storage mapping
+	KeyType     string            `json:"keyType,omitempty"`
+	ValueType   string            `json:"valueType,omitempty"`
+	Doc         string            `json:"doc"`
+	Annotations map[string]string `json:"annotations"`
+}
+
+// IR returns the contract as serialized for generator plugins. Slices and maps are never nil, so they
+// are serialized as empty arrays and objects rather than null
+func (q QInterfaceBuilder) IR() IRContract {
+	contract := IRContract{
+		Name:            q.ContractName,
+		Attributes:      irMap(q.Attributes),
+		Implements:      irStrings(q.Implements),
+		Roles:           irStrings(q.Roles),
+		Constants:       []IRConst{},
+		Storage:         irTypes(q.Storage),
+		Functions:       []IRFunction{},
+		ReentrancyGuard: q.UsesReentrancyGuard(),
+	}
+	for _, constant := range q.Constants {
+		contract.Constants = append(contract.Constants, IRConst{constant.Name, constant.Type, constant.Value})
+	}
+	for _, function := range q.Functions {
+		contract.Functions = append(contract.Functions, IRFunction{
+			Name:         function.FuncName,
+			CName:        function.CName(),
+			Selector:     function.GenHashedFuncIdentifier(q.ContractName),
+			Signature:    function.CanonicalSignature(),
+			Payable:      function.Payable,
+			NonReentrant: function.NonReentrant,
+			OnlyRoles:    irStrings(function.OnlyRoles),
+			Overloaded:   function.Overloaded,
+			Doc:          function.Doc,
+			Annotations:  irMap(function.Annotations),
+			Inputs:       irTypes(function.Inputs),
+			Outputs:      irTypes(function.Outputs),
+		})
+	}
+	return contract
+}
+
+func irTypes(types []QType) []IRType {
+	irTypes := []IRType{}
+	for _, typ := range types {
+		irType := IRType{
+			Name:        typ.TypeName,
+			Type:        typ.Type,
+			BaseType:    getBaseType(typ.Type),
+			Array:       isArray(typ.Type),
+			Length:      typ.Length,
+			Context:     isContextType(typ.Type),
+			Doc:         typ.Doc,
+			Annotations: irMap(typ.Annotations),
+		}
+		if isMap(typ.Type) {
+			irType.KeyType, irType.ValueType = getMapTypes(typ.Type)
+		}
+		irTypes = append(irTypes, irType)
+	}
+	return irTypes
+}
+
+func irStrings(strings []string) []string {
+	if strings == nil {
+		return []string{}
+	}
+	return strings
+}
+
+func irMap(m map[string]string) map[string]string {
+	if m == nil {
+		return map[string]string{}
+	}
+	return m
+}
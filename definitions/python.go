@@ -0,0 +1,267 @@
+package definitions
+
+import (
+	"strconv"
+	"strings"
+)
+
+// pythonKeywords can't be used as names in Python, pythonName appends an underscore to them as PEP 8 suggests
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true, "async": true,
+	"await": true, "break": true, "class": true, "continue": true, "def": true, "del": true, "elif": true,
+	"else": true, "except": true, "finally": true, "for": true, "from": true, "global": true, "if": true,
+	"import": true, "in": true, "is": true, "lambda": true, "nonlocal": true, "not": true, "or": true,
+	"pass": true, "raise": true, "return": true, "try": true, "while": true, "with": true, "yield": true,
+	// names the generated module and its methods declare or use, which a parameter would shadow
+	"self": true, "_options": true, "struct": true, "warnings": true, "dataclass": true, "Callable": true,
+	"List": true, "Optional": true, "Sequence": true, "Sized": true, "UniversalAddress": true,
+	"CallOptions": true, "Stack": true, "_ADDRESS_SIZE": true, "_pack_address": true,
+	"_unpack_address": true, "_check_length": true,
+}
+
+// pythonName is name as a Python identifier
+func pythonName(name string) string {
+	if pythonKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// GenFileHeaderPython generates the file header comment of GenFileHeaderC as Python comments
+func (q QInterfaceBuilder) GenFileHeaderPython() string {
+	var comment []string
+	for _, line := range q.fileHeaderLines() {
+		comment = append(comment, "# "+line)
+	}
+	if len(comment) == 0 {
+		return ""
+	}
+	return strings.Join(comment, "\n") + "\n"
+}
+
+// GenConstPython generates a module level constant
+func (c QConst) GenConstPython() string {
+	return pythonName(c.Name) + " = " + c.Value
+}
+
+// PythonResultName is the name of the dataclass holding the outputs of a function with several outputs
+func (q QFunc) PythonResultName() string {
+	name := q.CName()
+	return strings.ToUpper(name[:1]) + name[1:] + "Result"
+}
+
+// GenResultDataclassPython generates the dataclass holding the outputs of the function, or an empty string
+// when the function has less than two outputs and returns its only output or None
+func (q QFunc) GenResultDataclassPython() string {
+	if len(q.Outputs) < 2 {
+		return ""
+	}
+	lines := []string{
+		"@dataclass",
+		"class " + q.PythonResultName() + ":",
+		`    """outputs of ` + q.FuncName + `"""`,
+		"",
+	}
+	for _, output := range q.Outputs {
+		lines = append(lines, "    "+pythonName(output.TypeName)+": "+getPythonType(output))
+	}
+	return strings.Join(lines, "\n") + "\n\n\n"
+}
+
+// GenEncodePython generates the function returning the call data of a call to the function, in the order
+// the stack package documents
+func (q QFunc) GenEncodePython(contractName string) string {
+	lines := []string{
+		"def encode_" + q.CName() + "(" + q.pythonParams() + ") -> bytes:",
+		`    """returns the call data of a call to ` + q.FuncName + `"""`,
+		"    __s = Stack()",
+	}
+	inputs := q.encodedInputs()
+	for i := len(inputs) - 1; i >= 0; i-- {
+		lines = append(lines, "    "+getPythonPushStatement(inputs[i]))
+	}
+	lines = append(lines, `    __s.push_int("I", ID_`+contractName+"_"+q.CName()+")", "    return __s.encode()")
+	return strings.Join(lines, "\n")
+}
+
+// GenDecodePython generates the function decoding the outputs of the function from the data a call returned
+func (q QFunc) GenDecodePython() string {
+	lines := []string{
+		"def decode_" + q.CName() + "(__data: bytes) -> " + q.pythonReturnType() + ":",
+		`    """decodes the outputs of ` + q.FuncName + ` from the data a call returned"""`,
+	}
+	if len(q.Outputs) == 0 {
+		lines = append(lines, "    Stack.decode(__data)", "    return None")
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines, "    __s = Stack.decode(__data)")
+	var names []string
+	for i := len(q.Outputs) - 1; i >= 0; i-- {
+		lines = append(lines, "    "+pythonName(q.Outputs[i].TypeName)+" = "+getPythonPopStatement(q.Outputs[i]))
+	}
+	for _, output := range q.Outputs {
+		names = append(names, pythonName(output.TypeName))
+	}
+	if len(q.Outputs) == 1 {
+		lines = append(lines, "    return "+names[0])
+	} else {
+		lines = append(lines, "    return "+q.PythonResultName()+"("+strings.Join(names, ", ")+")")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// GenMethodPython generates the method calling the function through the class' call function. It takes
+// the CallOptions of the call as its last, optional, parameter, which defaults to the gas limit of the
+// function's @gas annotation
+func (q QFunc) GenMethodPython() string {
+	params := "self"
+	if encoded := q.pythonParams(); encoded != "" {
+		params += ", " + encoded
+	}
+	params += ", _options: Optional[CallOptions] = None"
+	var args []string
+	for _, input := range q.encodedInputs() {
+		args = append(args, pythonName(input.TypeName))
+	}
+	lines := []string{"def " + pythonName(q.CName()) + "(" + params + ") -> " + q.pythonReturnType() + ":"}
+	lines = append(lines, q.genDocPython("    ")...)
+	if deprecated, ok := q.Annotations["deprecated"]; ok {
+		message := strings.TrimSpace(q.FuncName + " is deprecated: " + deprecated)
+		if deprecated == "" {
+			message = q.FuncName + " is deprecated"
+		}
+		lines = append(lines, "    warnings.warn("+strconv.Quote(message)+", DeprecationWarning, stacklevel=2)")
+	}
+	gas := ""
+	if limit, ok := q.Annotations["gas"]; ok {
+		gas = "gas_limit=" + limit
+	}
+	lines = append(lines, "    if _options is None:", "        _options = CallOptions("+gas+")")
+	if !q.Payable {
+		lines = append(lines, "    if _options.value > 0:", `        raise ValueError("nonpayable function")`)
+	}
+	lines = append(lines, "    return decode_"+q.CName()+"(self._call(encode_"+q.CName()+"("+strings.Join(args, ", ")+"), _options))")
+	// indent the body within the class, leaving blank docstring lines empty
+	for i := 1; i < len(lines); i++ {
+		if lines[i] != "" {
+			lines[i] = "    " + lines[i]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// genDocPython generates the docstring of the method calling the function, indented by indent
+func (q QFunc) genDocPython(indent string) []string {
+	lines := []string{"calls " + q.FuncName + " on the contract"}
+	if q.Doc != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(q.Doc, "\n")...)
+	}
+	var params []string
+	for _, input := range q.encodedInputs() {
+		if doc := input.paramDoc(); doc != "" {
+			params = append(params, ":param "+pythonName(input.TypeName)+": "+doc)
+		}
+	}
+	for _, output := range q.Outputs {
+		if doc := output.paramDoc(); doc != "" {
+			params = append(params, ":return "+pythonName(output.TypeName)+": "+doc)
+		}
+	}
+	if len(params) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, params...)
+	}
+	if deprecated, ok := q.Annotations["deprecated"]; ok {
+		lines = append(lines, "", strings.TrimSpace(".. deprecated:: "+deprecated))
+	}
+	if len(lines) == 1 {
+		return []string{indent + `"""` + lines[0] + `"""`}
+	}
+	docstring := []string{indent + `"""` + lines[0]}
+	for _, line := range lines[1:] {
+		docstring = append(docstring, strings.TrimRight(indent+line, " "))
+	}
+	return append(docstring, indent+`"""`)
+}
+
+func (q QFunc) pythonParams() string {
+	var params []string
+	for _, input := range q.encodedInputs() {
+		params = append(params, pythonName(input.TypeName)+": "+getPythonType(input))
+	}
+	return strings.Join(params, ", ")
+}
+
+func (q QFunc) pythonReturnType() string {
+	switch len(q.Outputs) {
+	case 0:
+		return "None"
+	case 1:
+		return getPythonType(q.Outputs[0])
+	default:
+		return q.PythonResultName()
+	}
+}
+
+// getPythonType is the type hint of an input or output
+func getPythonType(typ QType) string {
+	if isArray(typ.Type) || isFixedArray(typ.Type) {
+		return "List[" + getPythonBaseType(getBaseType(typ.Type)) + "]"
+	}
+	return getPythonBaseType(typ.Type)
+}
+
+func getPythonBaseType(typ string) string {
+	if typ == "uniaddress" {
+		return "UniversalAddress"
+	}
+	return "int"
+}
+
+// getPythonStructFormat is the struct module format character of an integer type
+func getPythonStructFormat(typ string) string {
+	formats := map[string]string{
+		"uint8": "B", "uint16": "H", "uint32": "I", "uint64": "Q",
+		"int8": "b", "int16": "h", "int32": "i", "int64": "q",
+	}
+	return formats[typ]
+}
+
+// getPythonArrayLength is the length argument of the array methods of Stack, checking the number of elements
+// of fixed size arrays
+func getPythonArrayLength(typ QType) string {
+	if isFixedArray(typ.Type) {
+		return ", " + strconv.Itoa(typ.Length)
+	}
+	return ""
+}
+
+func getPythonPushStatement(typ QType) string {
+	base := getBaseType(typ.Type)
+	switch {
+	case (isArray(typ.Type) || isFixedArray(typ.Type)) && base == "uniaddress":
+		return "__s.push_addresses(" + pythonName(typ.TypeName) + getPythonArrayLength(typ) + ")"
+	case isArray(typ.Type) || isFixedArray(typ.Type):
+		return `__s.push_ints("` + getPythonStructFormat(base) + `", ` + pythonName(typ.TypeName) + getPythonArrayLength(typ) + ")"
+	case typ.Type == "uniaddress":
+		return "__s.push_address(" + pythonName(typ.TypeName) + ")"
+	default:
+		return `__s.push_int("` + getPythonStructFormat(typ.Type) + `", ` + pythonName(typ.TypeName) + ")"
+	}
+}
+
+func getPythonPopStatement(typ QType) string {
+	base := getBaseType(typ.Type)
+	length := strings.TrimPrefix(getPythonArrayLength(typ), ", ")
+	switch {
+	case (isArray(typ.Type) || isFixedArray(typ.Type)) && base == "uniaddress":
+		return "__s.pop_addresses(" + length + ")"
+	case isArray(typ.Type) || isFixedArray(typ.Type):
+		return `__s.pop_ints("` + getPythonStructFormat(base) + `"` + getPythonArrayLength(typ) + ")"
+	case typ.Type == "uniaddress":
+		return "__s.pop_address()"
+	default:
+		return `__s.pop_int("` + getPythonStructFormat(typ.Type) + `")`
+	}
+}
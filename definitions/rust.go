@@ -0,0 +1,306 @@
+package definitions
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// rustKeywords can't be used as names in Rust, rustName appends an underscore to them
+var rustKeywords = map[string]bool{
+	"as": true, "async": true, "await": true, "break": true, "const": true, "continue": true, "crate": true,
+	"dyn": true, "else": true, "enum": true, "extern": true, "false": true, "fn": true, "for": true, "gen": true,
+	"if": true, "impl": true, "in": true, "let": true, "loop": true, "match": true, "mod": true, "move": true,
+	"mut": true, "pub": true, "ref": true, "return": true, "self": true, "Self": true, "static": true,
+	"struct": true, "super": true, "trait": true, "true": true, "type": true, "unsafe": true, "use": true,
+	"where": true, "while": true, "abstract": true, "become": true, "box": true, "do": true, "final": true,
+	"macro": true, "override": true, "priv": true, "try": true, "typeof": true, "unsized": true,
+	"virtual": true, "yield": true, "union": true,
+}
+
+// rustName is name as a Rust identifier
+func rustName(name string) string {
+	if rustKeywords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// RustTraitName is the name of the trait the contract implements in Rust
+func (q QInterfaceBuilder) RustTraitName() string {
+	return rustName(q.ContractName)
+}
+
+// GenFileHeaderRust generates the file header comment of GenFileHeaderC as Rust line comments
+func (q QInterfaceBuilder) GenFileHeaderRust() string {
+	var comment []string
+	for _, line := range q.fileHeaderLines() {
+		comment = append(comment, "// "+line)
+	}
+	if len(comment) == 0 {
+		return ""
+	}
+	return strings.Join(comment, "\n") + "\n"
+}
+
+// GenConstRust generates a Rust constant declaration for the constant
+func (c QConst) GenConstRust() string {
+	return "pub const " + rustName(c.Name) + ": " + getRustBaseType(c.Type) + " = " + c.Value + ";"
+}
+
+// GenDocRust generates a Rust doc comment for the function, indented by indent. Encoding documents the call wrapper,
+// which leaves out caller context parameters. It returns an empty string when the function has no documentation
+func (q QFunc) GenDocRust(indent string, isEncoding bool) string {
+	var lines []string
+	if q.Doc != "" {
+		lines = append(lines, strings.Split(q.Doc, "\n")...)
+	}
+	if deprecated, ok := q.Annotations["deprecated"]; ok && !isEncoding {
+		// call wrappers carry a #[deprecated] attribute instead
+		lines = append(lines, strings.TrimSpace("Deprecated: "+deprecated))
+	}
+	var params []string
+	for _, input := range q.Inputs {
+		if doc := input.paramDoc(); doc != "" && !(isEncoding && isContextType(input.Type)) {
+			params = append(params, "* `"+rustName(input.TypeName)+"` - "+doc)
+		}
+	}
+	for _, output := range q.Outputs {
+		if doc := output.paramDoc(); doc != "" {
+			params = append(params, "* `"+rustName(output.TypeName)+"` (output) - "+doc)
+		}
+	}
+	if len(lines) > 0 && len(params) > 0 {
+		lines = append(lines, "")
+	}
+	lines = append(lines, params...)
+	var comment []string
+	for _, line := range lines {
+		comment = append(comment, strings.TrimRight(indent+"/// "+line, " "))
+	}
+	if len(comment) == 0 {
+		return ""
+	}
+	return strings.Join(comment, "\n") + "\n"
+}
+
+// GenTraitMethodRust generates the method of the contract's dispatcher trait implementing the function.
+// Inputs are handed over by value, caller context addresses by reference
+func (q QFunc) GenTraitMethodRust() string {
+	params := []string{"&mut self"}
+	for _, input := range q.Inputs {
+		params = append(params, rustName(input.TypeName)+": "+getRustType(input))
+	}
+	return "fn " + rustName(q.CName()) + "(" + strings.Join(params, ", ") + ")" + q.rustReturnType() + ";"
+}
+
+// GenCallRust generates a typed wrapper calling the function of a deployed contract. It returns the outputs,
+// or the call result when the call fails. The stack package documents the order of the inputs and outputs
+func (q QFunc) GenCallRust(contractName string) string {
+	var lines []string
+	if deprecated, ok := q.Annotations["deprecated"]; ok {
+		lines = append(lines, "#[deprecated(note = "+rustString(deprecated)+")]")
+	}
+	params := []string{"__address: &UniversalAddress", "__options: &CallOptions"}
+	for _, input := range q.encodedInputs() {
+		params = append(params, rustName(input.TypeName)+": "+getRustInputType(input))
+	}
+	lines = append(lines, "pub fn "+rustName(q.CName())+"("+strings.Join(params, ", ")+") -> Result<"+q.rustOutputsType()+", CallResult> {")
+	if !q.Payable {
+		lines = append(lines, "    if __options.value > 0 {", "        qtum::error(\"nonpayable function\");", "    }")
+	}
+	inputs := q.encodedInputs()
+	for i := len(inputs) - 1; i >= 0; i-- {
+		lines = append(lines, "    "+getRustPushStatement(inputs[i], rustName(inputs[i].TypeName), false)+";")
+	}
+	lines = append(lines, "    qtum::push32(ID_"+contractName+"_"+q.CName()+");")
+	lines = append(lines, "    let __result = qtum::call(__address, __options);")
+	lines = append(lines, "    if __result.error != qtum::CALL_SUCCESS {", "        return Err(__result);", "    }")
+	for i := len(q.Outputs) - 1; i >= 0; i-- {
+		lines = append(lines, "    let "+rustName(q.Outputs[i].TypeName)+" = "+getRustPopStatement(q.Outputs[i])+";")
+	}
+	lines = append(lines, "    Ok("+q.rustOutputsValue()+")", "}")
+	return strings.Join(lines, "\n")
+}
+
+// GenDispatchCodeRust generates the match arm body dispatching a call to the function, see GenDispatchCodeC
+func (q QFunc) GenDispatchCodeRust() string {
+	var statement []string
+	if !q.Payable {
+		statement = append(statement, "if qtum::exec().value_sent > 0 {", "    qtum::error(\"nonpayable function\");", "}")
+	}
+	if len(q.OnlyRoles) > 0 {
+		var checks []string
+		for _, role := range q.OnlyRoles {
+			checks = append(checks, "__contract.role_"+role+"() != qtum::exec().sender")
+		}
+		statement = append(statement, "if "+strings.Join(checks, " && ")+" {")
+		statement = append(statement, "    qtum::error(\"unauthorized: only "+strings.Join(q.OnlyRoles, " or ")+"\");", "}")
+	}
+	var args []string
+	for _, input := range q.Inputs {
+		name := rustName(input.TypeName)
+		args = append(args, name)
+		if isContextType(input.Type) {
+			statement = append(statement, "let "+name+" = "+getContextValueRust(input.Type)+";")
+			continue
+		}
+		statement = append(statement, "let "+name+" = "+getRustPopStatement(input)+";")
+		if maxlen, ok := input.Annotations["maxlen"]; ok {
+			statement = append(statement, "if "+name+".len() > "+maxlen+" {")
+			statement = append(statement, "    qtum::error(\""+input.TypeName+" is longer than "+maxlen+" elements\");", "}")
+		}
+	}
+	if q.NonReentrant {
+		statement = append(statement, "__nonreentrant_enter();")
+	}
+	call := "__contract." + rustName(q.CName()) + "(" + strings.Join(args, ", ") + ");"
+	if len(q.Outputs) > 0 {
+		call = "let " + q.rustOutputsValue() + " = " + call
+	}
+	statement = append(statement, call)
+	if q.NonReentrant {
+		statement = append(statement, "__nonreentrant_exit();")
+	}
+	for _, output := range q.Outputs {
+		statement = append(statement, getRustPushStatement(output, rustName(output.TypeName), true)+";")
+	}
+	return strings.Join(statement, "\n            ")
+}
+
+// rustReturnType is the " -> T" return type of a function returning its outputs, or nothing when it has none
+func (q QFunc) rustReturnType() string {
+	if len(q.Outputs) == 0 {
+		return ""
+	}
+	return " -> " + q.rustOutputsType()
+}
+
+// rustOutputsType is the type of the outputs: (), a single type or a tuple
+func (q QFunc) rustOutputsType() string {
+	var types []string
+	for _, output := range q.Outputs {
+		types = append(types, getRustType(output))
+	}
+	if len(types) == 1 {
+		return types[0]
+	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
+
+// rustOutputsValue is the expression or pattern holding the outputs, matching rustOutputsType
+func (q QFunc) rustOutputsValue() string {
+	var names []string
+	for _, output := range q.Outputs {
+		names = append(names, rustName(output.TypeName))
+	}
+	if len(names) == 1 {
+		return names[0]
+	}
+	return "(" + strings.Join(names, ", ") + ")"
+}
+
+// getRustType is the owned Rust type of an input or output
+func getRustType(typ QType) string {
+	switch {
+	case isContextType(typ.Type):
+		return getContextTypeRust(typ.Type)
+	case isFixedArray(typ.Type):
+		return "[" + getRustBaseType(getBaseType(typ.Type)) + "; " + strconv.Itoa(typ.Length) + "]"
+	case isArray(typ.Type):
+		return "Vec<" + getRustBaseType(getBaseType(typ.Type)) + ">"
+	default:
+		return getRustBaseType(typ.Type)
+	}
+}
+
+// getRustInputType is the type call wrappers take an input as, borrowing anything that is not an integer
+func getRustInputType(typ QType) string {
+	switch {
+	case isFixedArray(typ.Type):
+		return "&" + getRustType(typ)
+	case isArray(typ.Type):
+		return "&[" + getRustBaseType(getBaseType(typ.Type)) + "]"
+	case typ.Type == "uniaddress":
+		return "&UniversalAddressABI"
+	default:
+		return getRustType(typ)
+	}
+}
+
+func getRustBaseType(typ string) string {
+	switch typ {
+	case "uniaddress":
+		return "UniversalAddressABI"
+	default:
+		// uint64 becomes u64, int64 becomes i64
+		return strings.Replace(strings.Replace(typ, "uint", "u", 1), "int", "i", 1)
+	}
+}
+
+// getRustPushStatement pushes a value onto the call stack, owned values other than integers are borrowed first
+func getRustPushStatement(typ QType, value string, owned bool) string {
+	if owned && !isIntegerType(typ.Type) {
+		value = "&" + value
+	}
+	switch {
+	case isArray(typ.Type) || isFixedArray(typ.Type):
+		return "__push_words(" + value + ")"
+	case typ.Type == "uniaddress":
+		return "__push_item(" + value + ")"
+	case strings.HasPrefix(typ.Type, "int"):
+		return "qtum::push" + getIntegerWidth(typ.Type) + "(" + value + " as u" + getIntegerWidth(typ.Type) + ")"
+	default:
+		return "qtum::push" + getIntegerWidth(typ.Type) + "(" + value + ")"
+	}
+}
+
+// getRustPopStatement pops an input or output off the call stack
+func getRustPopStatement(typ QType) string {
+	switch {
+	case isFixedArray(typ.Type):
+		return fmt.Sprintf("__pop_array::<%v, %v>()", getRustBaseType(getBaseType(typ.Type)), typ.Length)
+	case isArray(typ.Type):
+		return "__pop_words::<" + getRustBaseType(getBaseType(typ.Type)) + ">()"
+	case typ.Type == "uniaddress":
+		return "__pop_item::<UniversalAddressABI>()"
+	case strings.HasPrefix(typ.Type, "int"):
+		return "qtum::pop" + getIntegerWidth(typ.Type) + "() as " + getRustBaseType(typ.Type)
+	default:
+		return "qtum::pop" + getIntegerWidth(typ.Type) + "()"
+	}
+}
+
+// getIntegerWidth returns the number of bits of an integer type such as uint64
+func getIntegerWidth(typ string) string {
+	return strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int")
+}
+
+func getContextTypeRust(typ string) string {
+	switch typ {
+	case "@sender", "@origin":
+		return "&UniversalAddressABI"
+	case "@value":
+		return "u64"
+	default:
+		return ""
+	}
+}
+
+func getContextValueRust(typ string) string {
+	switch typ {
+	case "@sender":
+		return "&qtum::exec().sender"
+	case "@origin":
+		return "&qtum::exec().origin"
+	case "@value":
+		return "qtum::exec().value_sent"
+	default:
+		return ""
+	}
+}
+
+// rustString quotes a string as a Rust string literal
+func rustString(value string) string {
+	return "\"" + strings.NewReplacer("\\", "\\\\", "\"", "\\\"").Replace(value) + "\""
+}
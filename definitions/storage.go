@@ -0,0 +1,240 @@
+package definitions
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"strings"
+)
+
+// GenStorageKeyC generates the storage key of a slot as a C byte array initializer.
+// Keys are derived as sha256("<contractName>.<slotName>") so they stay stable across builds
+// and never depend on the order slots are declared in
+func (typ QType) GenStorageKeyC(contractName string) string {
+	key := sha256.Sum256([]byte(contractName + "." + typ.TypeName))
+	var keyBytes []string
+	for _, b := range key {
+		keyBytes = append(keyBytes, fmt.Sprintf("0x%02x", b))
+	}
+	return "{" + strings.Join(keyBytes, ", ") + "}"
+}
+
+// GenStoragePrototypesC generates the prototypes of the typed accessors of a slot,
+// get and set for plain slots and get, set, has and delete for mappings
+func (typ QType) GenStoragePrototypesC(contractName string) string {
+	if isMap(typ.Type) {
+		return strings.Join(typ.genMapSignaturesC(contractName), ";\n") + ";"
+	}
+	getter, setter := typ.genStorageSignaturesC(contractName)
+	return getter + ";\n" + setter + ";"
+}
+
+// GenStorageAccessorsC generates the implementation of the typed accessors of a slot
+func (typ QType) GenStorageAccessorsC(contractName string) string {
+	if isMap(typ.Type) {
+		return typ.genMapAccessorsC(contractName)
+	}
+	getter, setter := typ.genStorageSignaturesC(contractName)
+	key := "KEY_" + contractName + "_" + typ.TypeName
+	var statement []string
+	if typ.Type == "uniaddress" {
+		statement = append(statement, getter+"{")
+		statement = append(statement, "\tmemset("+typ.TypeName+", 0, sizeof(UniversalAddressABI));")
+		statement = append(statement, "\tqtumLoad("+key+", sizeof("+key+"), "+typ.TypeName+", sizeof(UniversalAddressABI));")
+		statement = append(statement, "}")
+		statement = append(statement, "")
+		statement = append(statement, setter+"{")
+		statement = append(statement, "\tqtumStore("+key+", sizeof("+key+"), "+typ.TypeName+", sizeof(UniversalAddressABI));")
+		statement = append(statement, "}")
+	} else {
+		statement = append(statement, getter+"{")
+		statement = append(statement, "\t"+typ.Type+"_t "+typ.TypeName+" = 0;")
+		statement = append(statement, "\tqtumLoad("+key+", sizeof("+key+"), &"+typ.TypeName+", sizeof("+typ.TypeName+"));")
+		statement = append(statement, "\treturn "+typ.TypeName+";")
+		statement = append(statement, "}")
+		statement = append(statement, "")
+		statement = append(statement, setter+"{")
+		statement = append(statement, "\tqtumStore("+key+", sizeof("+key+"), &"+typ.TypeName+", sizeof("+typ.TypeName+"));")
+		statement = append(statement, "}")
+	}
+	return strings.Join(statement, "\n")
+}
+
+func (typ QType) genStorageSignaturesC(contractName string) (string, string) {
+	prefix := contractName + "_storage_"
+	if typ.Type == "uniaddress" {
+		return "void " + prefix + "get_" + typ.TypeName + "(UniversalAddressABI* " + typ.TypeName + ")",
+			"void " + prefix + "set_" + typ.TypeName + "(const UniversalAddressABI* " + typ.TypeName + ")"
+	}
+	return typ.Type + "_t " + prefix + "get_" + typ.TypeName + "()",
+		"void " + prefix + "set_" + typ.TypeName + "(" + typ.Type + "_t " + typ.TypeName + ")"
+}
+
+// genMapAccessorsC generates the accessors of a mapping. The key of an entry is sha256 of the slot key
+// followed by the raw bytes of the mapping key, so entries of different mappings never collide
+// and every entry key has the fixed size of a plain slot key
+func (typ QType) genMapAccessorsC(contractName string) string {
+	keyType, valueType := getMapTypes(typ.Type)
+	signatures := typ.genMapSignaturesC(contractName)
+	slotKey := "KEY_" + contractName + "_" + typ.TypeName
+	keySize := "sizeof(key)"
+	keyAddress := "&key"
+	if keyType == "uniaddress" {
+		keySize = "sizeof(UniversalAddressABI)"
+		keyAddress = "key"
+	}
+	deriveKey := []string{
+		"\tuint8_t __data[sizeof(" + slotKey + ") + " + keySize + "];",
+		"\tuint8_t __key[32];",
+		"\tmemcpy(__data, " + slotKey + ", sizeof(" + slotKey + "));",
+		"\tmemcpy(__data + sizeof(" + slotKey + "), " + keyAddress + ", " + keySize + ");",
+		"\t__sha256(__data, sizeof(__data), __key);",
+	}
+
+	var statement []string
+	// get
+	statement = append(statement, signatures[0]+"{")
+	statement = append(statement, deriveKey...)
+	if valueType == "uniaddress" {
+		statement = append(statement, "\tmemset(value, 0, sizeof(UniversalAddressABI));")
+		statement = append(statement, "\tqtumLoad(__key, sizeof(__key), value, sizeof(UniversalAddressABI));")
+	} else {
+		statement = append(statement, "\t"+valueType+"_t value = 0;")
+		statement = append(statement, "\tqtumLoad(__key, sizeof(__key), &value, sizeof(value));")
+		statement = append(statement, "\treturn value;")
+	}
+	statement = append(statement, "}", "")
+	// set
+	statement = append(statement, signatures[1]+"{")
+	statement = append(statement, deriveKey...)
+	if valueType == "uniaddress" {
+		statement = append(statement, "\tqtumStore(__key, sizeof(__key), value, sizeof(UniversalAddressABI));")
+	} else {
+		statement = append(statement, "\tqtumStore(__key, sizeof(__key), &value, sizeof(value));")
+	}
+	statement = append(statement, "}", "")
+	// has
+	statement = append(statement, signatures[2]+"{")
+	statement = append(statement, deriveKey...)
+	if valueType == "uniaddress" {
+		statement = append(statement, "\tUniversalAddressABI value;")
+	} else {
+		statement = append(statement, "\t"+valueType+"_t value;")
+	}
+	statement = append(statement, "\treturn qtumLoad(__key, sizeof(__key), &value, sizeof(value)) > 0;")
+	statement = append(statement, "}", "")
+	// delete
+	statement = append(statement, signatures[3]+"{")
+	statement = append(statement, deriveKey...)
+	statement = append(statement, "\tqtumStore(__key, sizeof(__key), NULL, 0);")
+	statement = append(statement, "}")
+	return strings.Join(statement, "\n")
+}
+
+// GenSha256C generates the static sha256 function the accessors of mappings derive entry keys with,
+// or nothing if the contract has no mappings
+func (q QInterfaceBuilder) GenSha256C() string {
+	for _, typ := range q.Storage {
+		if isMap(typ.Type) {
+			return sha256C
+		}
+	}
+	return ""
+}
+
+const sha256C = `static const uint32_t __sha256_k[64] = {
+	0x428a2f98, 0x71374491, 0xb5c0fbcf, 0xe9b5dba5, 0x3956c25b, 0x59f111f1, 0x923f82a4, 0xab1c5ed5,
+	0xd807aa98, 0x12835b01, 0x243185be, 0x550c7dc3, 0x72be5d74, 0x80deb1fe, 0x9bdc06a7, 0xc19bf174,
+	0xe49b69c1, 0xefbe4786, 0x0fc19dc6, 0x240ca1cc, 0x2de92c6f, 0x4a7484aa, 0x5cb0a9dc, 0x76f988da,
+	0x983e5152, 0xa831c66d, 0xb00327c8, 0xbf597fc7, 0xc6e00bf3, 0xd5a79147, 0x06ca6351, 0x14292967,
+	0x27b70a85, 0x2e1b2138, 0x4d2c6dfc, 0x53380d13, 0x650a7354, 0x766a0abb, 0x81c2c92e, 0x92722c85,
+	0xa2bfe8a1, 0xa81a664b, 0xc24b8b70, 0xc76c51a3, 0xd192e819, 0xd6990624, 0xf40e3585, 0x106aa070,
+	0x19a4c116, 0x1e376c08, 0x2748774c, 0x34b0bcb5, 0x391c0cb3, 0x4ed8aa4a, 0x5b9cca4f, 0x682e6ff3,
+	0x748f82ee, 0x78a5636f, 0x84c87814, 0x8cc70208, 0x90befffa, 0xa4506ceb, 0xbef9a3f7, 0xc67178f2
+};
+
+#define __SHA256_ROTR(x, n) (((x) >> (n)) | ((x) << (32 - (n))))
+
+static void __sha256_block(uint32_t state[8], const uint8_t block[64]){
+	uint32_t w[64];
+	uint32_t v[8];
+	for(int i = 0; i < 16; i++){
+		w[i] = (uint32_t)block[4*i] << 24 | (uint32_t)block[4*i+1] << 16 | (uint32_t)block[4*i+2] << 8 | block[4*i+3];
+	}
+	for(int i = 16; i < 64; i++){
+		uint32_t s0 = __SHA256_ROTR(w[i-15], 7) ^ __SHA256_ROTR(w[i-15], 18) ^ (w[i-15] >> 3);
+		uint32_t s1 = __SHA256_ROTR(w[i-2], 17) ^ __SHA256_ROTR(w[i-2], 19) ^ (w[i-2] >> 10);
+		w[i] = w[i-16] + s0 + w[i-7] + s1;
+	}
+	memcpy(v, state, sizeof(v));
+	for(int i = 0; i < 64; i++){
+		uint32_t s1 = __SHA256_ROTR(v[4], 6) ^ __SHA256_ROTR(v[4], 11) ^ __SHA256_ROTR(v[4], 25);
+		uint32_t t1 = v[7] + s1 + ((v[4] & v[5]) ^ (~v[4] & v[6])) + __sha256_k[i] + w[i];
+		uint32_t s0 = __SHA256_ROTR(v[0], 2) ^ __SHA256_ROTR(v[0], 13) ^ __SHA256_ROTR(v[0], 22);
+		uint32_t t2 = s0 + ((v[0] & v[1]) ^ (v[0] & v[2]) ^ (v[1] & v[2]));
+		memmove(v + 1, v, 7 * sizeof(uint32_t));
+		v[4] += t1;
+		v[0] = t1 + t2;
+	}
+	for(int i = 0; i < 8; i++){
+		state[i] += v[i];
+	}
+}
+
+//sha256 of the entry key of a mapping, the qtum library has no hash function of its own
+static void __sha256(const uint8_t* data, size_t size, uint8_t hash[32]){
+	uint32_t state[8] = {0x6a09e667, 0xbb67ae85, 0x3c6ef372, 0xa54ff53a, 0x510e527f, 0x9b05688c, 0x1f83d9ab, 0x5be0cd19};
+	uint8_t block[64];
+	size_t done = 0;
+	for(; size - done >= 64; done += 64){
+		__sha256_block(state, data + done);
+	}
+	memset(block, 0, sizeof(block));
+	memcpy(block, data + done, size - done);
+	block[size - done] = 0x80;
+	if(size - done >= 56){
+		__sha256_block(state, block);
+		memset(block, 0, sizeof(block));
+	}
+	for(int i = 0; i < 8; i++){
+		block[63 - i] = (uint8_t)((uint64_t)size * 8 >> (8 * i));
+	}
+	__sha256_block(state, block);
+	for(int i = 0; i < 32; i++){
+		hash[i] = (uint8_t)(state[i / 4] >> (24 - 8 * (i % 4)));
+	}
+}
+`
+
+func (typ QType) genMapSignaturesC(contractName string) []string {
+	keyType, valueType := getMapTypes(typ.Type)
+	prefix := contractName + "_storage_"
+	keyParam := keyType + "_t key"
+	if keyType == "uniaddress" {
+		keyParam = "const UniversalAddressABI* key"
+	}
+	getter := valueType + "_t " + prefix + "get_" + typ.TypeName + "(" + keyParam + ")"
+	setter := "void " + prefix + "set_" + typ.TypeName + "(" + keyParam + ", " + valueType + "_t value)"
+	if valueType == "uniaddress" {
+		getter = "void " + prefix + "get_" + typ.TypeName + "(" + keyParam + ", UniversalAddressABI* value)"
+		setter = "void " + prefix + "set_" + typ.TypeName + "(" + keyParam + ", const UniversalAddressABI* value)"
+	}
+	return []string{
+		getter,
+		setter,
+		"int " + prefix + "has_" + typ.TypeName + "(" + keyParam + ")",
+		"void " + prefix + "delete_" + typ.TypeName + "(" + keyParam + ")",
+	}
+}
+
+func isMap(typ string) bool {
+	return strings.HasPrefix(typ, "map<") && strings.HasSuffix(typ, ">")
+}
+
+// getMapTypes splits a map<key,value> type into its key and value types
+func getMapTypes(typ string) (string, string) {
+	types := strings.SplitN(strings.TrimSuffix(strings.TrimPrefix(typ, "map<"), ">"), ",", 2)
+	if len(types) != 2 {
+		return types[0], ""
+	}
+	return types[0], types[1]
+}
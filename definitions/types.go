@@ -0,0 +1,59 @@
+package definitions
+
+import "fmt"
+
+// LanguageType returns the type typ is represented as in the code generated for language, one of the --lang
+// values c, cpp, go, python, rust and ts. C arrays are pointers to their first element, as in the generated
+// signatures. Caller context parameters only have a type in the languages with a dispatcher, and storage
+// mappings have none at all
+func (typ QType) LanguageType(language string) (string, error) {
+	if isMap(typ.Type) {
+		return "", fmt.Errorf("the mapping %v has no %v type", typ.Type, language)
+	}
+	if isContextType(typ.Type) && language != "c" && language != "cpp" && language != "rust" {
+		return "", fmt.Errorf("the caller context parameter %v has no %v type", typ.Type, language)
+	}
+	switch language {
+	case "c":
+		switch {
+		case isContextType(typ.Type):
+			return getContextTypeC(typ.Type), nil
+		case isArray(typ.Type) || isFixedArray(typ.Type):
+			return getCBaseType(getBaseType(typ.Type)) + "*", nil
+		default:
+			return getCBaseType(typ.Type), nil
+		}
+	case "cpp":
+		return getCppType(typ), nil
+	case "go":
+		return getGoType(typ), nil
+	case "python":
+		return getPythonType(typ), nil
+	case "rust":
+		return getRustType(typ), nil
+	case "ts":
+		return getTSType(typ), nil
+	default:
+		return "", fmt.Errorf("unknown language %v", language)
+	}
+}
+
+// IsArray reports whether typ is a dynamic array such as uint8[], fixed size arrays are not
+func (typ QType) IsArray() bool {
+	return isArray(typ.Type)
+}
+
+// IsFixedArray reports whether typ is a fixed size array such as uint8[32] or uint8[KEY_LEN]
+func (typ QType) IsFixedArray() bool {
+	return isFixedArray(typ.Type)
+}
+
+// IsContext reports whether typ is a caller context parameter such as @sender
+func (typ QType) IsContext() bool {
+	return isContextType(typ.Type)
+}
+
+// BaseType is the type of a single element of an array, or the type itself for anything else
+func (typ QType) BaseType() string {
+	return getBaseType(typ.Type)
+}
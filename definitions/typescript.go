@@ -0,0 +1,266 @@
+package definitions
+
+import (
+	"strconv"
+	"strings"
+)
+
+// tsReservedWords can't be used as parameter or variable names in TypeScript, tsName appends an underscore
+// to them. constructor is included as functions are generated as class methods
+var tsReservedWords = map[string]bool{
+	"await": true, "break": true, "case": true, "catch": true, "class": true, "const": true, "continue": true,
+	"debugger": true, "default": true, "delete": true, "do": true, "else": true, "enum": true, "export": true,
+	"extends": true, "false": true, "finally": true, "for": true, "function": true, "if": true,
+	"implements": true, "import": true, "in": true, "instanceof": true, "interface": true, "let": true,
+	"new": true, "null": true, "package": true, "private": true, "protected": true, "public": true,
+	"return": true, "static": true, "super": true, "switch": true, "this": true, "throw": true, "true": true,
+	"try": true, "typeof": true, "var": true, "void": true, "while": true, "with": true, "yield": true,
+	"constructor": true,
+}
+
+// tsName is name as a TypeScript identifier
+func tsName(name string) string {
+	if tsReservedWords[name] {
+		return name + "_"
+	}
+	return name
+}
+
+// GenFileHeaderTS generates the file header comment of GenFileHeaderC as TypeScript line comments
+func (q QInterfaceBuilder) GenFileHeaderTS() string {
+	var comment []string
+	for _, line := range q.fileHeaderLines() {
+		comment = append(comment, "// "+line)
+	}
+	if len(comment) == 0 {
+		return ""
+	}
+	return strings.Join(comment, "\n") + "\n"
+}
+
+// GenConstTS generates an exported constant, a bigint for 64 bit types
+func (c QConst) GenConstTS() string {
+	value := c.Value
+	if isBigIntType(c.Type) {
+		value += "n"
+	}
+	return "export const " + tsName(c.Name) + " = " + value + ";"
+}
+
+// TSName is the name of the function in the names of its encoder, decoder and result interface
+func (q QFunc) TSName() string {
+	name := q.CName()
+	return strings.ToUpper(name[:1]) + name[1:]
+}
+
+// GenResultInterfaceTS generates the interface holding the outputs of the function, or an empty string
+// when the function has less than two outputs and returns its only output or nothing
+func (q QFunc) GenResultInterfaceTS() string {
+	if len(q.Outputs) < 2 {
+		return ""
+	}
+	lines := []string{
+		"/** outputs of " + q.FuncName + " */",
+		"export interface " + q.TSName() + "Result {",
+	}
+	for _, output := range q.Outputs {
+		lines = append(lines, "  "+output.TypeName+": "+getTSType(output)+";")
+	}
+	return strings.Join(append(lines, "}"), "\n") + "\n\n"
+}
+
+// GenEncodeTS generates the function returning the call data of a call to the function, in the order
+// the stack package documents
+func (q QFunc) GenEncodeTS(contractName string) string {
+	lines := []string{
+		"/** returns the call data of a call to " + q.FuncName + " */",
+		"export function encode" + q.TSName() + "(" + q.tsParams() + "): Uint8Array {",
+		"  const __s = new Stack();",
+	}
+	inputs := q.encodedInputs()
+	for i := len(inputs) - 1; i >= 0; i-- {
+		lines = append(lines, "  "+getTSPushStatement(inputs[i]))
+	}
+	lines = append(lines, `  __s.pushNumber("uint32", ID_`+contractName+"_"+q.CName()+");", "  return __s.encode();", "}")
+	return strings.Join(lines, "\n")
+}
+
+// GenDecodeTS generates the function decoding the outputs of the function from the data a call returned
+func (q QFunc) GenDecodeTS() string {
+	lines := []string{
+		"/** decodes the outputs of " + q.FuncName + " from the data a call returned */",
+		"export function decode" + q.TSName() + "(__data: Uint8Array): " + q.tsReturnType() + " {",
+	}
+	if len(q.Outputs) == 0 {
+		lines = append(lines, "  Stack.decode(__data);", "}")
+		return strings.Join(lines, "\n")
+	}
+	lines = append(lines, "  const __s = Stack.decode(__data);")
+	for i := len(q.Outputs) - 1; i >= 0; i-- {
+		lines = append(lines, "  const "+tsName(q.Outputs[i].TypeName)+" = "+getTSPopStatement(q.Outputs[i])+";")
+	}
+	if len(q.Outputs) == 1 {
+		lines = append(lines, "  return "+tsName(q.Outputs[0].TypeName)+";", "}")
+		return strings.Join(lines, "\n")
+	}
+	var fields []string
+	for _, output := range q.Outputs {
+		if name := tsName(output.TypeName); name != output.TypeName {
+			fields = append(fields, output.TypeName+": "+name)
+		} else {
+			fields = append(fields, name)
+		}
+	}
+	lines = append(lines, "  return { "+strings.Join(fields, ", ")+" };", "}")
+	return strings.Join(lines, "\n")
+}
+
+// GenMethodTS generates the async method calling the function through the class' transport. It takes the
+// CallOptions of the call as its last, optional, parameter, which defaults to the gas limit of the function's
+// @gas annotation
+func (q QFunc) GenMethodTS() string {
+	params := q.tsParams()
+	if params != "" {
+		params += ", "
+	}
+	params += "__options: CallOptions = {}"
+	var args []string
+	for _, input := range q.encodedInputs() {
+		args = append(args, tsName(input.TypeName))
+	}
+	lines := q.genDocTS()
+	lines = append(lines, "async "+tsName(q.CName())+"("+params+"): Promise<"+q.tsReturnType()+"> {")
+	if !q.Payable {
+		lines = append(lines, "  if ((__options.value ?? 0n) > 0n) {", `    throw new Error("nonpayable function");`, "  }")
+	}
+	options := "__options"
+	if gas, ok := q.Annotations["gas"]; ok {
+		options = "{ gasLimit: " + gas + "n, ...__options }"
+	}
+	lines = append(lines, "  const __data = await this.__transport(encode"+q.TSName()+"("+strings.Join(args, ", ")+"), "+options+");")
+	if len(q.Outputs) == 0 {
+		lines = append(lines, "  decode"+q.TSName()+"(__data);", "}")
+	} else {
+		lines = append(lines, "  return decode"+q.TSName()+"(__data);", "}")
+	}
+	return strings.Join(lines, "\n  ")
+}
+
+// genDocTS generates the JSDoc comment of the method calling the function
+func (q QFunc) genDocTS() []string {
+	lines := []string{"calls " + q.FuncName + " on the contract"}
+	if q.Doc != "" {
+		lines = append(lines, "")
+		lines = append(lines, strings.Split(q.Doc, "\n")...)
+	}
+	for _, input := range q.encodedInputs() {
+		if doc := input.paramDoc(); doc != "" {
+			lines = append(lines, "@param "+tsName(input.TypeName)+" "+doc)
+		}
+	}
+	for _, output := range q.Outputs {
+		if doc := output.paramDoc(); doc != "" {
+			lines = append(lines, "@returns "+output.TypeName+" "+doc)
+		}
+	}
+	if deprecated, ok := q.Annotations["deprecated"]; ok {
+		lines = append(lines, strings.TrimSpace("@deprecated "+deprecated))
+	}
+	if len(lines) == 1 {
+		return []string{"/** " + lines[0] + " */"}
+	}
+	comment := []string{"/**"}
+	for _, line := range lines {
+		comment = append(comment, strings.TrimRight(" * "+line, " "))
+	}
+	return append(comment, " */")
+}
+
+func (q QFunc) tsParams() string {
+	var params []string
+	for _, input := range q.encodedInputs() {
+		params = append(params, tsName(input.TypeName)+": "+getTSType(input))
+	}
+	return strings.Join(params, ", ")
+}
+
+func (q QFunc) tsReturnType() string {
+	switch len(q.Outputs) {
+	case 0:
+		return "void"
+	case 1:
+		return getTSType(q.Outputs[0])
+	default:
+		return q.TSName() + "Result"
+	}
+}
+
+// isBigIntType tells whether an integer type is too wide for a number and maps to bigint
+func isBigIntType(typ string) bool {
+	return typ == "uint64" || typ == "int64"
+}
+
+// getTSType is the TypeScript type of an input or output
+func getTSType(typ QType) string {
+	if isArray(typ.Type) || isFixedArray(typ.Type) {
+		return getTSBaseType(getBaseType(typ.Type)) + "[]"
+	}
+	return getTSBaseType(typ.Type)
+}
+
+func getTSBaseType(typ string) string {
+	switch {
+	case typ == "uniaddress":
+		return "UniversalAddress"
+	case isBigIntType(typ):
+		return "bigint"
+	default:
+		return "number"
+	}
+}
+
+// getTSArrayLength is the length argument of the array methods of Stack, checking the number of elements
+// of fixed size arrays
+func getTSArrayLength(typ QType) string {
+	if isFixedArray(typ.Type) {
+		return ", " + strconv.Itoa(typ.Length)
+	}
+	return ""
+}
+
+// getTSStackMethod is the name of the Stack methods moving a value of typ, following push or pop
+func getTSStackMethod(typ QType) string {
+	base := getBaseType(typ.Type)
+	method := "Number"
+	switch {
+	case base == "uniaddress":
+		method = "Address"
+	case isBigIntType(base):
+		method = "BigInt"
+	}
+	if isArray(typ.Type) || isFixedArray(typ.Type) {
+		if method == "Address" {
+			return "Addresses"
+		}
+		return method + "s"
+	}
+	return method
+}
+
+func getTSPushStatement(typ QType) string {
+	base := getBaseType(typ.Type)
+	args := tsName(typ.TypeName) + getTSArrayLength(typ)
+	if base != "uniaddress" {
+		args = `"` + base + `", ` + args
+	}
+	return "__s.push" + getTSStackMethod(typ) + "(" + args + ");"
+}
+
+func getTSPopStatement(typ QType) string {
+	base := getBaseType(typ.Type)
+	args := strings.TrimPrefix(getTSArrayLength(typ), ", ")
+	if base != "uniaddress" {
+		args = strings.TrimSuffix(`"`+base+`", `+args, ", ")
+	}
+	return "__s.pop" + getTSStackMethod(typ) + "(" + args + ")"
+}
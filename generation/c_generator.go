@@ -1,32 +1,48 @@
 package generation
 
-import (
-	"fmt"
-	"io"
-	"text/template"
-
-	"github.com/qtumproject/simple-abi/definitions"
-)
-
 // cDecodingTemplateImpl is a template used for generation of a .c file
 const cDecodingTemplateImpl = `{{ $contractName := .ContractName }}
-#include <stdlib.h>
+{{.GenFileHeaderC}}#include <stdlib.h>
+#include <string.h>
 #include <qtum.h>
 
-//Function IDs
-{{range .Functions}}#define ID_{{$contractName}}_{{.FuncName}} {{.GenHashedFuncIdentifier $contractName}}
+{{if .Constants}}//Constants
+{{range .Constants}}{{.GenDefineC $.ContractName}}
+{{end}}
+{{end}}//Function IDs
+{{range .Functions}}#define ID_{{$contractName}}_{{.CName}} {{.GenHashedFuncIdentifier $contractName}}
 {{end}}
 //prototypes 
-{{range $i, $x := .Functions }}void {{.GenFuncSignatureC $contractName false}};
+{{range $i, $x := .Functions }}{{.GenDocC false}}void {{.GenFuncSignatureC $contractName false}};
+{{end}}{{range .Roles}}void {{$contractName}}_role_{{.}}(UniversalAddressABI* __role);
+{{end}}{{if .UsesReentrancyGuard}}
+//reentrancy guard, qtumError reverts the storage writes of the failing call, so the lock
+//never outlives a call that errors while holding it
+static const char {{$contractName}}_lock_key[] = "__{{$contractName}}_reentrancy_lock";
+
+void {{$contractName}}_nonreentrant_enter(){
+    uint8_t locked = 0;
+    qtumLoad({{$contractName}}_lock_key, sizeof({{$contractName}}_lock_key) - 1, &locked, sizeof(locked));
+    if(locked){
+        qtumError("reentrant call");
+    }
+    locked = 1;
+    qtumStore({{$contractName}}_lock_key, sizeof({{$contractName}}_lock_key) - 1, &locked, sizeof(locked));
+}
+
+void {{$contractName}}_nonreentrant_exit(){
+    uint8_t locked = 0;
+    qtumStore({{$contractName}}_lock_key, sizeof({{$contractName}}_lock_key) - 1, &locked, sizeof(locked));
+}
 {{end}}
 //dispatch code
 void dispatch(){
     uint32_t fn;
-    if(qtumPop(&fn, sizeof(fn) != sizeof(fn))){
+    if(qtumPop(&fn, sizeof(fn)) != sizeof(fn)){
         //fallback function/error
     }
     switch(fn){
-    	{{range $i, $x := .Functions }}case ID_{{$contractName}}_{{- $x.FuncName}}:
+    	{{range $i, $x := .Functions }}case ID_{{$contractName}}_{{- $x.CName}}:
     	{
 		{{.GenDispatchCodeC $contractName}}
 	}{{printf "\n\t"}}{{end -}}
@@ -37,90 +53,84 @@ void dispatch(){
 }`
 
 const cEncodingTemplateImpl = `{{ $contractName := .ContractName }}
-#include <stdlib.h>
+{{.GenFileHeaderC}}#include <stdlib.h>
 #include <qtum.h>
 
-//Function IDs
-{{range .Functions}}#define ID_{{$contractName}}_{{.FuncName}} {{.GenHashedFuncIdentifier $contractName}}
+{{if .Constants}}//Constants
+{{range .Constants}}{{.GenDefineC $.ContractName}}
+{{end}}
+{{end}}//Function IDs
+{{range .Functions}}#define ID_{{$contractName}}_{{.CName}} {{.GenHashedFuncIdentifier $contractName}}
 {{end}}
-{{range .Functions}}QtumCallResult  {{.GenFuncSignatureC $contractName true}}{
-{{.GenFuncCallQtum $contractName}}
+{{range .Functions}}{{.GenDocC true}}QtumCallResult  {{.GenFuncSignatureC $contractName true}}{
+	{{.GenFuncCallQtum $contractName}}
 }
 
 {{end}}`
 
 const headerEncodingTemplateImpl = `{{ $contractName := .ContractName }}
-#ifndef {{$contractName}}ABI_H
+{{.GenFileHeaderC}}#ifndef {{$contractName}}ABI_H
 #define {{$contractName}}ABI_H
 
-//Function IDs
-{{range .Functions}}#ifndef ID_{{$contractName}}_{{.FuncName}}
-#define ID_{{$contractName}}_{{.FuncName}} {{.GenHashedFuncIdentifier $contractName}}
+{{if .Constants}}//Constants
+{{range .Constants}}{{.GenDefineC $.ContractName}}
+{{end}}
+{{end}}//Function IDs
+{{range .Functions}}#ifndef ID_{{$contractName}}_{{.CName}}
+#define ID_{{$contractName}}_{{.CName}} {{.GenHashedFuncIdentifier $contractName}}
 #endif
 {{end}}
 
-{{range .Functions}}QtumCallResult  {{.GenFuncSignatureC $contractName true}};
+{{range .Functions}}{{.GenDocC true}}QtumCallResult  {{.GenFuncSignatureC $contractName true}};
 
 {{end}}
 #endif`
 
 const headerDecodingTemplateImpl = `{{ $contractName := .ContractName }}
-#ifndef {{$contractName}}DISPATCHER_H
+{{.GenFileHeaderC}}#ifndef {{$contractName}}DISPATCHER_H
 #define {{$contractName}}DISPATCHER_H
 
-//Function IDs
-{{range .Functions}}#ifndef ID_{{$contractName}}_{{.FuncName}}
-#define ID_{{$contractName}}_{{.FuncName}} {{.GenHashedFuncIdentifier $contractName}}
+{{if .Constants}}//Constants
+{{range .Constants}}{{.GenDefineC $.ContractName}}
+{{end}}
+{{end}}//Function IDs
+{{range .Functions}}#ifndef ID_{{$contractName}}_{{.CName}}
+#define ID_{{$contractName}}_{{.CName}} {{.GenHashedFuncIdentifier $contractName}}
 #endif
 {{end}}
 
 void dispatch();
 
-{{range $i, $x := .Functions }}void {{.GenFuncSignatureC $contractName false}};
+{{range $i, $x := .Functions }}{{.GenDocC false}}void {{.GenFuncSignatureC $contractName false}};
 {{end}}
+{{if .Roles}}//role accessors, implement these to load the address holding each role
+{{range .Roles}}void {{$contractName}}_role_{{.}}(UniversalAddressABI* __role);
+{{end}}
+{{end}}{{if .UsesReentrancyGuard}}//reentrancy guard held while nonreentrant implementations run
+void {{$contractName}}_nonreentrant_enter();
+void {{$contractName}}_nonreentrant_exit();
 
+{{end}}
 #endif
 `
 
-// TemplateType is an enum used to tell what template the function GenerateTemplate should generate
-type TemplateType int
-
-//EncodeC generates a C encoding template, DecodeC generates a C decoding template and so on and so forth
-const (
-	EncodeC TemplateType = iota
-	DecodeC
-	EncodeH
-	DecodeH
-)
-
-// GenerateTemplate takes in a QInterfaceBuilder, and defines a file for a decoding template to be used
-// to generate a file from
-func GenerateTemplate(builder definitions.QInterfaceBuilder, name string, output io.Writer, typ TemplateType) error {
-	errMsg := "Error in decode template generation: %v"
-	var toParse string
-
-	switch typ {
-	case EncodeC:
-		toParse = cEncodingTemplateImpl
-	case DecodeC:
-		toParse = cDecodingTemplateImpl
-	case EncodeH:
-		toParse = headerEncodingTemplateImpl
-	case DecodeH:
-		toParse = headerDecodingTemplateImpl
-	default:
-		panic("invalid type selected")
-	}
+const cStorageTemplateImpl = `{{ $contractName := .ContractName }}
+{{.GenFileHeaderC}}#include <stdlib.h>
+#include <string.h>
+#include <qtum.h>
+
+//storage keys, derived as sha256("<contract>.<slot>"), mapping entries as sha256 of the slot key and the raw key bytes
+{{range .Storage}}static const uint8_t KEY_{{$contractName}}_{{.TypeName}}[] = {{.GenStorageKeyC $contractName}};
+{{end}}
+{{.GenSha256C}}{{range .Storage}}{{.GenDocC}}{{.GenStorageAccessorsC $contractName}}
 
-	templ, err := template.New(name).Parse(toParse)
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
-	}
+{{end}}`
 
-	err = templ.Execute(output, builder)
-	if err != nil {
-		return fmt.Errorf(errMsg, err)
-	}
+const headerStorageTemplateImpl = `{{ $contractName := .ContractName }}
+{{.GenFileHeaderC}}#ifndef {{$contractName}}STORAGE_H
+#define {{$contractName}}STORAGE_H
 
-	return nil
-}
+{{range .Storage}}{{.GenDocC}}{{.GenStoragePrototypesC $contractName}}
+
+{{end}}#endif
+`
@@ -2,273 +2,480 @@ package generation
 
 import (
 	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
 	"testing"
 
 	def "github.com/qtumproject/simple-abi/definitions"
+	"github.com/qtumproject/simple-abi/parser"
 )
 
-const decodeTest1 = `
-#include <stdlib.h>
-#include <qtum.h>
-
-//Function IDs
-#define ID_MyContract_myFunction 0x996c38c3
-#define ID_MyContract_otherFunction 0x01c66199
+func TestContextParameters(t *testing.T) {
+	withContext := def.QFunc{
+		FuncName: "myFunction",
+		Inputs: []def.QType{
+			def.QType{Type: "@sender", TypeName: "caller"},
+			def.QType{Type: "uint8", TypeName: "somevar"},
+			def.QType{Type: "@value", TypeName: "amt"},
+		},
+	}
+	withoutContext := def.QFunc{
+		FuncName: "myFunction",
+		Inputs: []def.QType{
+			def.QType{Type: "uint8", TypeName: "somevar"},
+		},
+	}
+	builder := def.QInterfaceBuilder{ContractName: "MyContract", Functions: []def.QFunc{withContext}}
 
-//prototypes
-void MyContract_myFunction(uint8_t somevar, int64_t othervar, uint8_t* somereturn, int32_t* otherreturn);
-void MyContract_otherFunction(uint8_t somevar, uint32_t* somereturn);
+	if got, want := withContext.GenHashedFuncIdentifier("MyContract"), withoutContext.GenHashedFuncIdentifier("MyContract"); got != want {
+		t.Errorf("Expected context parameters to be left out of the selector: got %v, want %v", got, want)
+	}
 
-//dispatch code
-void dispatch(){
-	uint32_t fn;
-	if(qtumPop(&fn, sizeof(fn) != sizeof(fn)){
-		//fallback function/error
-	}
-	switch(fn){
-	    case ID_MyContract_myFunction:
-	    {
-		    uint8_t somevar = qtumPop8();
-		    int64_t othervar = qtumPop64();
-		    uint8_t somereturn = 0;
-		    int32_t otherreturn = 0;
-		    MyContract_myFunction(uint8_t somevar, int64_t othervar, &somereturn, &otherreturn)
-		    qtumPush32(xret);
-			qtumPush64(yret);
-		    break;
-	    }
-	    case ID_MyContract_otherFunction:
-	    {
-		    uint8_t somevar = qtumPop8();
-		    uint32_t somereturn = 0;
-		    MyContract_otherFunction(uint8_t somevar, &somereturn)
-		    qtumPush32(somereturn);
-		    break;
-	    }
-	    default:
-		    //fallback function / error
-	}
-}`
+	var b bytes.Buffer
+	if err := GenerateTemplate(builder, "contextDecode", &b, DecodeC); err != nil {
+		t.Fatalf("Unexpected error in template generation of contextDecode: %v", err)
+	}
+	for _, want := range []string{
+		"void MyContract_myFunction_dispatch(const UniversalAddressABI* caller, uint8_t somevar, uint64_t amt);",
+		"const UniversalAddressABI* caller = &qtumExec->sender;",
+		"uint64_t amt = qtumExec->valueSent;",
+		"MyContract_myFunction_dispatch(caller, somevar, amt);",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected decoding template to contain %q, got %v", want, b.String())
+		}
+	}
 
-const decodeTest2 = `
-#include <stdlib.h>
-#include <qtum.h>
+	b.Reset()
+	if err := GenerateTemplate(builder, "contextEncode", &b, EncodeC); err != nil {
+		t.Fatalf("Unexpected error in template generation of contextEncode: %v", err)
+	}
+	if !strings.Contains(b.String(), "MyContract_myFunction(const UniversalAddress *__address, const QtumCallOptions* __options, uint8_t somevar)") {
+		t.Errorf("Expected context parameters to be left out of the encoding signature, got %v", b.String())
+	}
+	if strings.Contains(b.String(), "caller") || strings.Contains(b.String(), "amt") {
+		t.Errorf("Expected no context parameters to be pushed by the encoder, got %v", b.String())
+	}
+}
 
-//Function IDs
-#define ID_MyContract_myFunction 0x6985f0c9
+func TestRoleGuard(t *testing.T) {
+	var b bytes.Buffer
+	builder := def.QInterfaceBuilder{
+		ContractName: "MyContract",
+		Roles:        []string{"owner"},
+		Functions: []def.QFunc{
+			def.QFunc{
+				FuncName:  "mint",
+				Inputs:    []def.QType{def.QType{Type: "uint64", TypeName: "amount"}},
+				OnlyRoles: []string{"owner"},
+			},
+		},
+	}
+	if err := GenerateTemplate(builder, "roleDecode", &b, DecodeC); err != nil {
+		t.Fatalf("Unexpected error in template generation of roleDecode: %v", err)
+	}
+	got := b.String()
 
-//prototypes
-void MyContract_myFunction(UniversalAddressABI* addressvar, UniversalAddressABI** addressreturn);
+	// an unauthorized sender must hit qtumError before any input is popped or the implementation runs
+	guard := "if(memcmp(&__role_owner, &qtumExec->sender, sizeof(UniversalAddressABI)) != 0) {\n\t\t\tqtumError(\"unauthorized: only owner\");\n\t\t}"
+	guardAt := strings.Index(got, guard)
+	if guardAt == -1 {
+		t.Fatalf("Expected the dispatcher to reject unauthorized senders, got %v", got)
+	}
+	if loadAt := strings.Index(got, "MyContract_role_owner(&__role_owner);"); loadAt == -1 || loadAt > guardAt {
+		t.Errorf("Expected the owner role to be loaded before the sender check, got %v", got)
+	}
+	if popAt := strings.Index(got, "qtumPop64()"); popAt < guardAt {
+		t.Errorf("Expected the sender check to run before inputs are popped, got %v", got)
+	}
+	if callAt := strings.Index(got, "MyContract_mint_dispatch(amount);"); callAt < guardAt {
+		t.Errorf("Expected the sender check to run before the implementation is called, got %v", got)
+	}
+	if !strings.Contains(got, "void MyContract_role_owner(UniversalAddressABI* __role);") {
+		t.Errorf("Expected a prototype for the owner role accessor, got %v", got)
+	}
+}
 
-//dispatch code
-void dispatch(){
-	uint32_t fn;
-	if(qtumPop(&fn, sizeof(fn) != sizeof(fn)){
-		//fallback function/error
-	}
-	switch(fn){
-		case ID_MyContract_myFunction:
-		{
-			UniversalAddressABI* addressvar = malloc(sizeof(UniversalAddressABI));
-			qtumPopExact(addressvar, sizeof(UniversalAddressABI));
-			UniversalAddressABI* addressreturn = NULL;
-			MyContract_myFunction(UniversalAddressABI* addressvar, &addressreturn)
-			qtumPopExact(addressreturn, sizeof(UniversalAddressABI));
-			break;
+// TestCEncoderCompiles builds the C encoder generated for every golden contract against the mock qtum.h
+func TestCEncoderCompiles(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc is needed to build the encoder")
+	}
+	dir := t.TempDir()
+	for _, input := range goldenInputs {
+		builders, err := parser.Parse(input, false)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %v: %v", input, err)
+		}
+		for _, builder := range builders {
+			if builder.IsInterface {
+				continue
+			}
+			encoder := filepath.Join(dir, builder.ContractName+EncodeC)
+			generate(t, builder, encoder, EncodeC)
+			gcc := exec.Command("gcc", "-Wall", "-Werror", "-I", filepath.Join("testdata", "roundtrip"), "-c", "-o", encoder+".o", encoder)
+			if out, err := gcc.CombinedOutput(); err != nil {
+				t.Errorf("Unexpected error building %v: %v\n%s", filepath.Base(encoder), err, out)
+			}
 		}
-		default:
-			//fallback function / error
 	}
-}`
-
-const encodeTest1 = `
-#include <stdlib.h>
-#include <qtum.h>
+}
 
-//Function IDs
-#define ID_MyContract_myFunction 0x996c38c3
-#define ID_MyContract_otherFunction 0x01c66199
+// TestCRoundTrip calls the generated dispatcher of testdata/golden/Token.abi through the generated encoder, both built
+// into testdata/roundtrip/c/main.c, whose qtumCall dispatches in the same process
+func TestCRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc is needed to build the C round trip")
+	}
+	builder := parseToken(t)
+	dir := t.TempDir()
+	generate(t, builder, filepath.Join(dir, "TokenABI.c"), EncodeC)
+	generate(t, builder, filepath.Join(dir, "TokenDispatcher.c"), DecodeC)
+	generate(t, builder, filepath.Join(dir, "TokenDispatcher.h"), DecodeH)
 
-QtumCallResult  MyContract_myFunction(UniversalAddress __address, QtumCallOptions* __options, uint8_t somevar, int64_t othervar, uint8_t* somereturn, int32_t* otherreturn){
-	qtumPush8(somevar);
-	qtumPush64(othervar);
-	qtumPush32(ID_MyContract_myFunction);
-	QtumCallResult r = qtumCall(__address, __options);
-	if(r.error == QTUM_CALL_SUCCESS){
-		*somereturn = qtumPop8();
-		*otherreturn = qtumPop32();
-	}
-	return r;
+	program := filepath.Join(dir, "roundtrip")
+	gcc := exec.Command("gcc", "-Wall", "-I", filepath.Join("testdata", "roundtrip"), "-I", dir, "-o", program,
+		filepath.Join(dir, "TokenABI.c"), filepath.Join(dir, "TokenDispatcher.c"), filepath.Join("testdata", "roundtrip", "c", "main.c"))
+	if out, err := gcc.CombinedOutput(); err != nil {
+		t.Fatalf("Unexpected error building the C round trip: %v\n%s", err, out)
+	}
+	out, err := exec.Command(program).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unexpected error running the C round trip: %v\n%s", err, out)
+	}
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "roundtrip", "c", "expected.txt"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("Unexpected round trip, got:\n%s\nwant:\n%s", out, want)
+	}
 }
 
-QtumCallResult  MyContract_otherFunction(UniversalAddress __address, QtumCallOptions* __options, uint8_t somevar, uint32_t* somereturn){
-	qtumPush8(somevar);
-	qtumPush32(ID_MyContract_otherFunction);
-	QtumCallResult r = qtumCall(__address, __options);
-	if(r.error == QTUM_CALL_SUCCESS){
-		*somereturn = qtumPop32();
-	}
-	return r;
+// TestReentrancyGuard runs the dispatcher of a nonreentrant function in testdata/reentrancy/main.c, which rejects
+// a nested call and has to leave no lock behind after a call failed with qtumError
+func TestReentrancyGuard(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc is needed to build the dispatcher")
+	}
+	builder := def.QInterfaceBuilder{
+		ContractName: "Guard",
+		Functions: []def.QFunc{
+			def.QFunc{
+				FuncName:     "withdraw",
+				Inputs:       []def.QType{def.QType{Type: "uint8", TypeName: "mode"}},
+				NonReentrant: true,
+			},
+		},
+	}
+	dir := t.TempDir()
+	generate(t, builder, filepath.Join(dir, "GuardDispatcher.c"), DecodeC)
+	generate(t, builder, filepath.Join(dir, "GuardDispatcher.h"), DecodeH)
+	program := filepath.Join(dir, "guard")
+	gcc := exec.Command("gcc", "-I", filepath.Join("testdata", "roundtrip"), "-I", dir, "-o", program,
+		filepath.Join(dir, "GuardDispatcher.c"), filepath.Join("testdata", "reentrancy", "main.c"))
+	if out, err := gcc.CombinedOutput(); err != nil {
+		t.Fatalf("Unexpected error building the dispatcher: %v\n%s", err, out)
+	}
+	out, err := exec.Command(program).Output()
+	if err != nil {
+		t.Fatalf("Unexpected error running the dispatcher: %v\n%s", err, out)
+	}
+	want := `withdraw mode=0
+-> ok
+withdraw mode=1
+error: reentrant call
+nested call rejected
+-> ok
+withdraw mode=2
+error: withdraw failed
+-> failed
+withdraw mode=0
+-> ok
+`
+	if string(out) != want {
+		t.Errorf("Unexpected calls, got:\n%swant:\n%s", out, want)
+	}
+
+	var b bytes.Buffer
+	builder.Functions[0].NonReentrant = false
+	if err := GenerateTemplate(builder, "noLockDecode", &b, DecodeC); err != nil {
+		t.Fatalf("Unexpected error in template generation of noLockDecode: %v", err)
+	}
+	if strings.Contains(b.String(), "nonreentrant") {
+		t.Errorf("Expected no reentrancy guard without nonreentrant functions, got %v", b.String())
+	}
 }
 
-`
+func TestStorageTemplate(t *testing.T) {
+	var b bytes.Buffer
+	builder := def.QInterfaceBuilder{
+		ContractName: "MyContract",
+		Storage: []def.QType{
+			def.QType{Type: "uint64", TypeName: "balance"},
+			def.QType{Type: "uniaddress", TypeName: "owner"},
+			def.QType{Type: "map<uniaddress,uint64>", TypeName: "balances"},
+		},
+	}
+	if err := GenerateTemplate(builder, "storageC", &b, StorageC); err != nil {
+		t.Fatalf("Unexpected error in template generation of storageC: %v", err)
+	}
+	for _, want := range []string{
+		"static const uint8_t KEY_MyContract_balance[] = {0xb7, 0x82, ",
+		"uint64_t MyContract_storage_get_balance(){",
+		"\tqtumStore(KEY_MyContract_balance, sizeof(KEY_MyContract_balance), &balance, sizeof(balance));",
+		"void MyContract_storage_get_owner(UniversalAddressABI* owner){",
+		"\tqtumLoad(KEY_MyContract_owner, sizeof(KEY_MyContract_owner), owner, sizeof(UniversalAddressABI));",
+		"static void __sha256(const uint8_t* data, size_t size, uint8_t hash[32]){",
+		"uint64_t MyContract_storage_get_balances(const UniversalAddressABI* key){\n\tuint8_t __data[sizeof(KEY_MyContract_balances) + sizeof(UniversalAddressABI)];\n\tuint8_t __key[32];\n\tmemcpy(__data, KEY_MyContract_balances, sizeof(KEY_MyContract_balances));\n\tmemcpy(__data + sizeof(KEY_MyContract_balances), key, sizeof(UniversalAddressABI));\n\t__sha256(__data, sizeof(__data), __key);",
+		"void MyContract_storage_set_balances(const UniversalAddressABI* key, uint64_t value){",
+		"int MyContract_storage_has_balances(const UniversalAddressABI* key){",
+		"void MyContract_storage_delete_balances(const UniversalAddressABI* key){",
+		"\tqtumStore(__key, sizeof(__key), NULL, 0);",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected storage template to contain %q, got %v", want, b.String())
+		}
+	}
 
-const encodeTest2 = `
-#include <stdlib.h>
+	b.Reset()
+	if err := GenerateTemplate(builder, "storageH", &b, StorageH); err != nil {
+		t.Fatalf("Unexpected error in template generation of storageH: %v", err)
+	}
+	if !strings.Contains(b.String(), "void MyContract_storage_set_owner(const UniversalAddressABI* owner);") {
+		t.Errorf("Expected storage header to declare the owner setter, got %v", b.String())
+	}
+}
+
+// mapKeysMain stores an entry of every mapping of TestStorageMapKeys, printing the keys qtumStore is called with
+const mapKeysMain = `#include <stdio.h>
+#include <string.h>
 #include <qtum.h>
+#include "MyContractStorage.h"
 
-//Function IDs
-#define ID_MyContract_myFunction 0x6985f0c9
+size_t qtumLoad(const void* key, size_t keySize, void* value, size_t maxSize){
+    return 0;
+}
 
-QtumCallResult  MyContract_myFunction(UniversalAddress __address, QtumCallOptions* __options, UniversalAddressABI* addressvar, UniversalAddressABI** addressreturn){
-	qtumPush(addressvar);
-	qtumPush32(ID_MyContract_myFunction);
-	QtumCallResult r = qtumCall(__address, __options);
-	if(r.error == QTUM_CALL_SUCCESS){
-		if(addressreturn == NULL){
-			addressreturn = malloc(sizeof(UniversalAddressABI));
-		}
-		if(addressreturn == NULL){
-			qtumErase();
-		}else{
-			qtumPop(addressreturn, sizeof(UniversalAddressABI));
-		}
-	}
-	return r;
+void qtumStore(const void* key, size_t keySize, const void* value, size_t size){
+    for(size_t i = 0; i < keySize; i++){
+        printf("%02x", ((const uint8_t*)key)[i]);
+    }
+    printf("\n");
 }
 
+int main(){
+    UniversalAddressABI holder;
+    holder.version = 2;
+    memset(holder.data, 0xab, sizeof(holder.data));
+    MyContract_storage_set_balances(&holder, 1);
+    MyContract_storage_set_flags(0x01020304, 1);
+    return 0;
+}
 `
 
-func TestDecodeTemplate1(t *testing.T) {
-	t.Skip()
-	var b bytes.Buffer
+// TestStorageMapKeys runs the generated accessors of mappings to check that entry keys are
+// sha256 of the slot key and the key bytes, for keys hashed in one and in two blocks
+func TestStorageMapKeys(t *testing.T) {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc is needed to build the storage accessors")
+	}
 	builder := def.QInterfaceBuilder{
 		ContractName: "MyContract",
-		Functions: []def.QFunc{
-			def.QFunc{
-				FuncName: "myFunction",
-				Inputs: []def.QType{
-					def.QType{Type: "uint8", TypeName: "somevar"},
-					def.QType{Type: "int64", TypeName: "othervar"},
-				},
-				Outputs: []def.QType{
-					def.QType{Type: "uint8", TypeName: "somereturn"},
-					def.QType{Type: "int32", TypeName: "otherreturn"},
-				},
-			},
-			def.QFunc{
-				FuncName: "otherFunction",
-				Inputs: []def.QType{
-					def.QType{Type: "uint8", TypeName: "somevar"},
-				},
-				Outputs: []def.QType{
-					def.QType{Type: "uint32", TypeName: "somereturn"},
-				},
-			},
+		Storage: []def.QType{
+			def.QType{Type: "map<uniaddress,uint64>", TypeName: "balances"},
+			def.QType{Type: "map<uint32,uint8>", TypeName: "flags"},
 		},
 	}
-	err := GenerateTemplate(builder, "decodeTest1", &b, DecodeC)
+	dir := t.TempDir()
+	generate(t, builder, filepath.Join(dir, "MyContractStorage.c"), StorageC)
+	generate(t, builder, filepath.Join(dir, "MyContractStorage.h"), StorageH)
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.c"), []byte(mapKeysMain), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	program := filepath.Join(dir, "mapkeys")
+	gcc := exec.Command("gcc", "-I", filepath.Join("testdata", "roundtrip"), "-I", dir, "-o", program,
+		filepath.Join(dir, "MyContractStorage.c"), filepath.Join(dir, "main.c"))
+	if out, err := gcc.CombinedOutput(); err != nil {
+		t.Fatalf("Unexpected error building the storage accessors: %v\n%s", err, out)
+	}
+	out, err := exec.Command(program).Output()
 	if err != nil {
-		t.Errorf("Unexpected error in template generation of decodeTest1: %v", err)
+		t.Fatalf("Unexpected error running the storage accessors: %v", err)
 	}
-	got := b.String()
-	want := decodeTest1
-	if got != want {
-		t.Errorf("DecodeTest1 got %v, want %v", got, want)
+
+	slotKey := func(name string) []byte {
+		key := sha256.Sum256([]byte("MyContract." + name))
+		return key[:]
+	}
+	holder := append([]byte{2, 0, 0, 0}, bytes.Repeat([]byte{0xab}, 32)...)
+	balances := sha256.Sum256(append(slotKey("balances"), holder...))
+	flags := sha256.Sum256(append(slotKey("flags"), 0x04, 0x03, 0x02, 0x01))
+	want := hex.EncodeToString(balances[:]) + "\n" + hex.EncodeToString(flags[:]) + "\n"
+	if string(out) != want {
+		t.Errorf("Unexpected entry keys, got:\n%swant:\n%s", out, want)
 	}
 }
 
-func TestDecodeTemplate2(t *testing.T) {
-	t.Skip()
-	var b bytes.Buffer
+func TestDocComments(t *testing.T) {
 	builder := def.QInterfaceBuilder{
 		ContractName: "MyContract",
 		Functions: []def.QFunc{
 			def.QFunc{
 				FuncName: "myFunction",
+				Doc:      "Does something.",
 				Inputs: []def.QType{
-					def.QType{Type: "uniaddress", TypeName: "addressvar"},
+					def.QType{Type: "@sender", TypeName: "caller", Doc: "who is calling"},
+					def.QType{Type: "uint8", TypeName: "somevar", Doc: "some value"},
 				},
 				Outputs: []def.QType{
-					def.QType{Type: "uniaddress", TypeName: "addressreturn"},
+					def.QType{Type: "uint32", TypeName: "somereturn", Doc: "the result"},
 				},
 			},
 		},
 	}
-	err := GenerateTemplate(builder, "decodeTest2", &b, DecodeC)
-	if err != nil {
-		t.Errorf("Unexpected error in template generation of decodeTest2: %v", err)
+	encodeDoc := "/**\n * Does something.\n * @param somevar some value\n * @param[out] somereturn the result\n */\nQtumCallResult  MyContract_myFunction("
+	decodeDoc := "/**\n * Does something.\n * @param caller who is calling\n * @param somevar some value\n * @param[out] somereturn the result\n */\nvoid MyContract_myFunction_dispatch("
+	for _, test := range []struct {
+		typ  string
+		want string
+	}{
+		{EncodeC, encodeDoc},
+		{EncodeH, encodeDoc},
+		{DecodeC, decodeDoc},
+		{DecodeH, decodeDoc},
+	} {
+		var b bytes.Buffer
+		if err := GenerateTemplate(builder, "docs", &b, test.typ); err != nil {
+			t.Fatalf("Unexpected error in template generation of docs: %v", err)
+		}
+		if !strings.Contains(b.String(), test.want) {
+			t.Errorf("Expected template %v to contain %q, got %v", test.typ, test.want, b.String())
+		}
 	}
-	got := b.String()
-	want := decodeTest2
-	if got != want {
-		t.Errorf("DecodeTest2 got %v, want %v", got, want)
+}
+
+func TestFileHeaderAttributes(t *testing.T) {
+	builder := def.QInterfaceBuilder{
+		ContractName: "MyContract",
+		Attributes: map[string]string{
+			"x-audited-by": "Some Firm",
+			"license":      "MIT",
+			"version":      "0.2.0",
+		},
+	}
+	want := "\n/*\n * MyContract\n * version: 0.2.0\n * license: MIT\n * x-audited-by: Some Firm\n */\n"
+	for _, typ := range []string{EncodeC, DecodeC, EncodeH, DecodeH, StorageC, StorageH} {
+		var b bytes.Buffer
+		if err := GenerateTemplate(builder, "header", &b, typ); err != nil {
+			t.Fatalf("Unexpected error in template generation of header: %v", err)
+		}
+		if !strings.HasPrefix(b.String(), want) {
+			t.Errorf("Expected template %v to start with %q, got %v", typ, want, b.String())
+		}
 	}
 }
 
-func TestEncodeTemplate1(t *testing.T) {
-	t.Skip()
-	var b bytes.Buffer
+func TestConstantsAndFixedArrays(t *testing.T) {
+	fixed := def.QFunc{
+		FuncName: "store",
+		Inputs:   []def.QType{def.QType{Type: "uint8[KEY_LEN]", TypeName: "key", Length: 32}},
+		Outputs:  []def.QType{def.QType{Type: "uint8[KEY_LEN]", TypeName: "stored", Length: 32}},
+	}
+	builder := def.QInterfaceBuilder{
+		ContractName: "MyContract",
+		Constants:    []def.QConst{def.QConst{Name: "KEY_LEN", Type: "uint8", Value: "32"}},
+		Functions:    []def.QFunc{fixed},
+	}
+	literal := fixed
+	literal.Inputs = []def.QType{def.QType{Type: "uint8[32]", TypeName: "key", Length: 32}}
+	literal.Outputs = []def.QType{def.QType{Type: "uint8[32]", TypeName: "stored", Length: 32}}
+	if fixed.GenHashedFuncIdentifier("MyContract") != literal.GenHashedFuncIdentifier("MyContract") {
+		t.Errorf("Expected array lengths to be hashed by value, not by constant name")
+	}
+
+	define := "#define MyContract_KEY_LEN ((uint8_t)32)\n"
+	for _, test := range []struct {
+		typ  string
+		want []string
+	}{
+		{EncodeH, []string{define}},
+		{DecodeH, []string{define}},
+		{EncodeC, []string{define, "const uint8_t* key, uint8_t* stored)", "qtumPush(key, MyContract_KEY_LEN * sizeof(*key));", "qtumPop(stored, MyContract_KEY_LEN * sizeof(*stored));"}},
+		{DecodeC, []string{define, "uint8_t key[MyContract_KEY_LEN];", "qtumPop(key, sizeof(key));", "uint8_t stored[MyContract_KEY_LEN] = {0};", "MyContract_store_dispatch(key, stored);", "qtumPush(stored, sizeof(stored));"}},
+	} {
+		var b bytes.Buffer
+		if err := GenerateTemplate(builder, "fixed", &b, test.typ); err != nil {
+			t.Fatalf("Unexpected error in template generation of fixed: %v", err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("Expected template %v to contain %q, got %v", test.typ, want, b.String())
+			}
+		}
+	}
+}
+
+func TestOverloadedFunctions(t *testing.T) {
 	builder := def.QInterfaceBuilder{
 		ContractName: "MyContract",
 		Functions: []def.QFunc{
-			def.QFunc{
-				FuncName: "myFunction",
-				Inputs: []def.QType{
-					def.QType{Type: "uint8", TypeName: "somevar"},
-					def.QType{Type: "int64", TypeName: "othervar"},
-				},
-				Outputs: []def.QType{
-					def.QType{Type: "uint8", TypeName: "somereturn"},
-					def.QType{Type: "int32", TypeName: "otherreturn"},
-				},
-			},
-			def.QFunc{
-				FuncName: "otherFunction",
-				Inputs: []def.QType{
-					def.QType{Type: "uint8", TypeName: "somevar"},
-				},
-				Outputs: []def.QType{
-					def.QType{Type: "uint32", TypeName: "somereturn"},
-				},
-			},
+			def.QFunc{FuncName: "transfer", Overloaded: true, Inputs: []def.QType{def.QType{Type: "uint64", TypeName: "amount"}}},
+			def.QFunc{FuncName: "transfer", Overloaded: true, Inputs: []def.QType{def.QType{Type: "uint64", TypeName: "amount"}, def.QType{Type: "uniaddress", TypeName: "to"}}},
 		},
 	}
-	err := GenerateTemplate(builder, "encodeTest1", &b, EncodeC)
-	if err != nil {
-		t.Errorf("Unexpected error in template generation of encodeTest1: %v", err)
+	if builder.Functions[0].GenHashedFuncIdentifier("MyContract") == builder.Functions[1].GenHashedFuncIdentifier("MyContract") {
+		t.Errorf("Expected overloads to have different function IDs")
 	}
-	got := b.String()
-	want := encodeTest1
-	if got != want {
-		t.Errorf("EncodeTest1 got %v, want %v", got, want)
+	for _, test := range []struct {
+		typ  string
+		want []string
+	}{
+		{EncodeH, []string{"#define ID_MyContract_transfer__u64 ", "#define ID_MyContract_transfer__u64_addr ", "MyContract_transfer__u64(", "MyContract_transfer__u64_addr("}},
+		{DecodeC, []string{"case ID_MyContract_transfer__u64:", "case ID_MyContract_transfer__u64_addr:", "MyContract_transfer__u64_dispatch(amount);", "MyContract_transfer__u64_addr_dispatch(amount, to);"}},
+	} {
+		var b bytes.Buffer
+		if err := GenerateTemplate(builder, "overloads", &b, test.typ); err != nil {
+			t.Fatalf("Unexpected error in template generation of overloads: %v", err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("Expected template %v to contain %q, got %v", test.typ, want, b.String())
+			}
+		}
 	}
 }
 
-func TestEncodeTemplate2(t *testing.T) {
-	t.Skip()
-	var b bytes.Buffer
+func TestAnnotations(t *testing.T) {
 	builder := def.QInterfaceBuilder{
 		ContractName: "MyContract",
 		Functions: []def.QFunc{
 			def.QFunc{
-				FuncName: "myFunction",
-				Inputs: []def.QType{
-					def.QType{Type: "uniaddress", TypeName: "addressvar"},
-				},
-				Outputs: []def.QType{
-					def.QType{Type: "uniaddress", TypeName: "addressreturn"},
-				},
+				FuncName:    "store",
+				Annotations: map[string]string{"gas": "50000", "deprecated": "use storeV2"},
+				Inputs:      []def.QType{def.QType{Type: "uint8[]", TypeName: "data", Annotations: map[string]string{"maxlen": "256"}}},
 			},
 		},
 	}
-	err := GenerateTemplate(builder, "encodeTest2", &b, EncodeC)
-	if err != nil {
-		t.Errorf("Unexpected error in template generation of encodeTest2: %v", err)
-	}
-	got := b.String()
-	want := encodeTest2
-	if got != want {
-		t.Errorf("EncodeTest2 got %v, want %v", got, want)
+	for _, test := range []struct {
+		typ  string
+		want []string
+	}{
+		{EncodeH, []string{" * @deprecated use storeV2", " * @param data (at most 256 elements)"}},
+		{DecodeC, []string{"if(data_sz > 256 * sizeof(*data)) {", "qtumError(\"data is longer than 256 elements\");"}},
+	} {
+		var b bytes.Buffer
+		if err := GenerateTemplate(builder, "annotations", &b, test.typ); err != nil {
+			t.Fatalf("Unexpected error in template generation of annotations: %v", err)
+		}
+		for _, want := range test.want {
+			if !strings.Contains(b.String(), want) {
+				t.Errorf("Expected template %v to contain %q, got %v", test.typ, want, b.String())
+			}
+		}
 	}
 }
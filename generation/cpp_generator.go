@@ -0,0 +1,126 @@
+package generation
+
+// cppClientTemplateImpl is a template used for generation of the header only <Contract>Client class
+const cppClientTemplateImpl = `{{ $contractName := .ContractName }}
+{{.GenFileHeaderC}}#ifndef {{$contractName}}CLIENT_HPP
+#define {{$contractName}}CLIENT_HPP
+
+#include <array>
+#include <cstdint>
+#include <optional>
+#include <vector>
+
+extern "C" {
+#include <qtum.h>
+}
+
+{{if .Constants}}//Constants
+{{range .Constants}}{{.GenDefineC $.ContractName}}
+{{end}}
+{{end}}//Function IDs
+{{range .Functions}}#ifndef ID_{{$contractName}}_{{.CName}}
+#define ID_{{$contractName}}_{{.CName}} {{.GenHashedFuncIdentifier $contractName}}
+#endif
+{{end}}
+/**
+ * {{$contractName}}Client calls the functions of a deployed {{$contractName}} contract
+ */
+class {{$contractName}}Client {
+public:
+    explicit {{$contractName}}Client(const UniversalAddress& address) : __address(address), __lastCallResult() {}
+
+{{range .Functions}}    {{.GenResultStructCpp}}
+
+{{end}}{{range .Functions}}{{.GenDocCpp "    " true}}    {{.GenClientMethodCpp $contractName}}
+
+{{end}}    /**
+     * lastCallResult is the result of the latest call, telling why a method returned nothing
+     */
+    const QtumCallResult& lastCallResult() const {
+        return __lastCallResult;
+    }
+
+private:
+    UniversalAddress __address;
+    QtumCallResult __lastCallResult;
+};
+
+#endif
+`
+
+// cppImplTemplateImpl is a template used for generation of the header only <Contract>Impl base class and its dispatcher
+const cppImplTemplateImpl = `{{ $contractName := .ContractName }}
+{{.GenFileHeaderC}}#ifndef {{$contractName}}IMPL_HPP
+#define {{$contractName}}IMPL_HPP
+
+#include <array>
+#include <cstdint>
+#include <cstring>
+#include <vector>
+
+extern "C" {
+#include <qtum.h>
+}
+
+{{if .Constants}}//Constants
+{{range .Constants}}{{.GenDefineC $.ContractName}}
+{{end}}
+{{end}}//Function IDs
+{{range .Functions}}#ifndef ID_{{$contractName}}_{{.CName}}
+#define ID_{{$contractName}}_{{.CName}} {{.GenHashedFuncIdentifier $contractName}}
+#endif
+{{end}}
+/**
+ * {{$contractName}}Impl is the base class of the contract implementation, dispatch calls its methods
+ */
+class {{$contractName}}Impl {
+public:
+    virtual ~{{$contractName}}Impl() {}
+{{range .Functions}}{{if gt (len .Outputs) 1}}
+    {{.GenResultStructCpp}}
+{{end}}{{end}}
+{{range .Functions}}{{.GenDocCpp "    " false}}    {{.GenImplMethodCpp}}
+{{end}}{{if .Roles}}
+    //role accessors, implement these to load the address holding each role
+{{range .Roles}}    virtual UniversalAddressABI role_{{.}}() = 0;
+{{end}}{{end}}};
+{{if .UsesReentrancyGuard}}
+//reentrancy guard, qtumError reverts the storage writes of the failing call, so the lock
+//never outlives a call that errors while holding it
+static const char {{$contractName}}_lock_key[] = "__{{$contractName}}_reentrancy_lock";
+
+inline void {{$contractName}}_nonreentrant_enter() {
+    uint8_t locked = 0;
+    qtumLoad({{$contractName}}_lock_key, sizeof({{$contractName}}_lock_key) - 1, &locked, sizeof(locked));
+    if(locked) {
+        qtumError("reentrant call");
+    }
+    locked = 1;
+    qtumStore({{$contractName}}_lock_key, sizeof({{$contractName}}_lock_key) - 1, &locked, sizeof(locked));
+}
+
+inline void {{$contractName}}_nonreentrant_exit() {
+    uint8_t locked = 0;
+    qtumStore({{$contractName}}_lock_key, sizeof({{$contractName}}_lock_key) - 1, &locked, sizeof(locked));
+}
+
+{{end}}
+/**
+ * dispatch pops the function ID and inputs of a call off the stack, calls the matching method
+ * of the contract and pushes its outputs
+ */
+inline void dispatch({{$contractName}}Impl& __contract) {
+    uint32_t __fn = qtumPop32();
+    switch(__fn) {
+{{range .Functions}}    case ID_{{$contractName}}_{{.CName}}:
+    {
+        {{.GenDispatchCodeCpp $contractName}}
+    }
+{{end}}    default:
+        //fallback function / error
+        break;
+    }
+}
+
+#endif
+`
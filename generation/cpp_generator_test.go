@@ -0,0 +1,39 @@
+package generation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+)
+
+// TestCppRoundTrip calls the generated TokenImpl through the generated TokenClient, both built into
+// testdata/roundtrip/cpp/main.cpp, whose qtumCall dispatches in the same process
+func TestCppRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("g++"); err != nil {
+		t.Skip("g++ is needed to build the C++ round trip")
+	}
+	builder := parseToken(t)
+	dir := t.TempDir()
+	generate(t, builder, filepath.Join(dir, "TokenClient.hpp"), EncodeCpp)
+	generate(t, builder, filepath.Join(dir, "TokenImpl.hpp"), DecodeCpp)
+
+	program := filepath.Join(dir, "roundtrip")
+	gpp := exec.Command("g++", "-std=c++17", "-Wall", "-Wno-deprecated-declarations", "-I", filepath.Join("testdata", "roundtrip"),
+		"-I", dir, "-o", program, filepath.Join("testdata", "roundtrip", "cpp", "main.cpp"))
+	if out, err := gpp.CombinedOutput(); err != nil {
+		t.Fatalf("Unexpected error building the C++ round trip: %v\n%s", err, out)
+	}
+	out, err := exec.Command(program).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unexpected error running the C++ round trip: %v\n%s", err, out)
+	}
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "roundtrip", "cpp", "expected.txt"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("Unexpected round trip, got:\n%s\nwant:\n%s", out, want)
+	}
+}
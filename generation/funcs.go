@@ -0,0 +1,151 @@
+package generation
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"text/template"
+	"unicode"
+
+	"github.com/qtumproject/simple-abi/definitions"
+)
+
+// TemplateFuncs returns the functions available to every template on top of the text/template built-ins and
+// the methods of the contract data. Arguments piped into a function are passed last, so {{.Doc | indent 4}}
+// calls indent 4 .Doc.
+//
+// Case conversion splits names into words at underscores and at the start of upper case runs, so numCoins,
+// num_coins and NumCoins all have the words num and coins:
+//   - lower, upper: the name in lower or upper case, e.g. numcoins and NUMCOINS
+//   - camelCase: numCoins
+//   - pascalCase: NumCoins
+//   - snakeCase: num_coins
+//   - screamingSnakeCase: NUM_COINS
+//
+// Types, applied to a definitions.QType such as an element of .Inputs:
+//   - langType language type: the type in a --lang language such as "rust", see QType.LanguageType
+//   - isArray type: whether a type name such as .Type is a dynamic array like uint8[]
+//   - isFixedArray type: whether a type name is a fixed size array like uint8[32]
+//   - isContext type: whether a type name is a caller context parameter like @sender
+//   - baseType type: the type of a single array element of a type name, e.g. uint8 for uint8[]
+//
+// Selectors of a function, given the contract name and a definitions.QFunc such as an element of .Functions:
+//   - selector contract function: the function ID as a hexadecimal number, e.g. 0x5a41ae21
+//   - selectorBytes contract function: the bytes of the ID as they are pushed onto the call stack, e.g. 0x21, 0xae, 0x41, 0x5a
+//
+// Strings:
+//   - join separator list: the list joined by the separator, e.g. {{.Roles | join ", "}}
+//   - names types: the names of a list of inputs, outputs or storage slots, e.g. {{names .Inputs | join ", "}}
+//   - indent n text: text with every line that isn't empty indented by n spaces
+func TemplateFuncs() template.FuncMap {
+	return template.FuncMap{
+		"lower":              strings.ToLower,
+		"upper":              strings.ToUpper,
+		"camelCase":          camelCase,
+		"pascalCase":         pascalCase,
+		"snakeCase":          snakeCase,
+		"screamingSnakeCase": screamingSnakeCase,
+		"langType":           langType,
+		"isArray":            func(typ string) bool { return definitions.QType{Type: typ}.IsArray() },
+		"isFixedArray":       func(typ string) bool { return definitions.QType{Type: typ}.IsFixedArray() },
+		"isContext":          func(typ string) bool { return definitions.QType{Type: typ}.IsContext() },
+		"baseType":           func(typ string) string { return definitions.QType{Type: typ}.BaseType() },
+		"selector":           selector,
+		"selectorBytes":      selectorBytes,
+		"join":               func(separator string, list []string) string { return strings.Join(list, separator) },
+		"names":              names,
+		"indent":             indent,
+	}
+}
+
+// words splits a name into its words, see TemplateFuncs
+func words(name string) []string {
+	var words []string
+	runes := []rune(name)
+	start := 0
+	for i := 0; i <= len(runes); i++ {
+		switch {
+		case i == len(runes) || runes[i] == '_':
+		case i > start && unicode.IsUpper(runes[i]) &&
+			(!unicode.IsUpper(runes[i-1]) || i+1 < len(runes) && unicode.IsLower(runes[i+1])):
+			// numCoins and HTTPCode both split before the C
+		default:
+			continue
+		}
+		if i > start {
+			words = append(words, strings.ToLower(string(runes[start:i])))
+		}
+		start = i
+		if i < len(runes) && runes[i] == '_' {
+			start = i + 1
+		}
+	}
+	return words
+}
+
+func camelCase(name string) string {
+	pascal := pascalCase(name)
+	if pascal == "" {
+		return ""
+	}
+	runes := []rune(pascal)
+	runes[0] = unicode.ToLower(runes[0])
+	return string(runes)
+}
+
+func pascalCase(name string) string {
+	var b strings.Builder
+	for _, word := range words(name) {
+		runes := []rune(word)
+		runes[0] = unicode.ToUpper(runes[0])
+		b.WriteString(string(runes))
+	}
+	return b.String()
+}
+
+func snakeCase(name string) string {
+	return strings.Join(words(name), "_")
+}
+
+func screamingSnakeCase(name string) string {
+	return strings.ToUpper(snakeCase(name))
+}
+
+func langType(language string, typ definitions.QType) (string, error) {
+	return typ.LanguageType(language)
+}
+
+func selector(contractName string, function definitions.QFunc) string {
+	return function.GenHashedFuncIdentifier(contractName)
+}
+
+// selectorBytes returns the bytes of the function ID in little endian order, the order qtumPush32 writes them in
+func selectorBytes(contractName string, function definitions.QFunc) (string, error) {
+	id, err := strconv.ParseUint(function.GenHashedFuncIdentifier(contractName), 0, 32)
+	if err != nil {
+		return "", err
+	}
+	var bytes []string
+	for i := 0; i < 4; i++ {
+		bytes = append(bytes, fmt.Sprintf("0x%02x", byte(id>>(8*i))))
+	}
+	return strings.Join(bytes, ", "), nil
+}
+
+func names(types []definitions.QType) []string {
+	var names []string
+	for _, typ := range types {
+		names = append(names, typ.TypeName)
+	}
+	return names
+}
+
+func indent(n int, text string) string {
+	lines := strings.Split(text, "\n")
+	for i, line := range lines {
+		if line != "" {
+			lines[i] = strings.Repeat(" ", n) + line
+		}
+	}
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,103 @@
+package generation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestCaseConversion(t *testing.T) {
+	var caseInputs = []struct {
+		input, camel, pascal, snake, screaming string
+	}{
+		{"numCoins", "numCoins", "NumCoins", "num_coins", "NUM_COINS"},
+		{"num_coins", "numCoins", "NumCoins", "num_coins", "NUM_COINS"},
+		{"NumCoins", "numCoins", "NumCoins", "num_coins", "NUM_COINS"},
+		{"MAX_RECIPIENTS", "maxRecipients", "MaxRecipients", "max_recipients", "MAX_RECIPIENTS"},
+		{"getHTTPCode", "getHttpCode", "GetHttpCode", "get_http_code", "GET_HTTP_CODE"},
+		{"transfer__addr_u64", "transferAddrU64", "TransferAddrU64", "transfer_addr_u64", "TRANSFER_ADDR_U64"},
+		{"_x", "x", "X", "x", "X"},
+		{"", "", "", "", ""},
+	}
+	for _, c := range caseInputs {
+		if got := camelCase(c.input); got != c.camel {
+			t.Errorf("Expected camelCase(%q) to be %q, got %q", c.input, c.camel, got)
+		}
+		if got := pascalCase(c.input); got != c.pascal {
+			t.Errorf("Expected pascalCase(%q) to be %q, got %q", c.input, c.pascal, got)
+		}
+		if got := snakeCase(c.input); got != c.snake {
+			t.Errorf("Expected snakeCase(%q) to be %q, got %q", c.input, c.snake, got)
+		}
+		if got := screamingSnakeCase(c.input); got != c.screaming {
+			t.Errorf("Expected screamingSnakeCase(%q) to be %q, got %q", c.input, c.screaming, got)
+		}
+	}
+}
+
+// TestTemplateFuncs generates testdata/golden/Token.abi with a custom template using every function of TemplateFuncs
+func TestTemplateFuncs(t *testing.T) {
+	dir := t.TempDir()
+	override := `{{$c := .ContractName}}{{range .Functions}}{{screamingSnakeCase .CName}} {{selector $c .}} [{{selectorBytes $c .}}]
+{{- range .Inputs}}{{if not (isContext .Type)}}
+  {{camelCase .TypeName}} {{langType "c" .}} | {{langType "cpp" .}} | {{langType "go" .}} | {{langType "python" .}} | {{langType "rust" .}} | {{langType "ts" .}}
+{{- if isArray .Type}} dynamic {{baseType .Type}}{{end}}{{if isFixedArray .Type}} fixed {{baseType .Type}}{{end}}{{end}}{{end}}
+  outputs [{{names .Outputs | join ", "}}]
+{{end}}{{.Roles | join "|" | upper}} {{lower "ABC"}} {{pascalCase .ContractName}} {{snakeCase "TokenABI"}}
+{{"a\n\nb" | indent 2}}
+`
+	if err := ioutil.WriteFile(filepath.Join(dir, "ABI.c.tmpl"), []byte(override), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	var b bytes.Buffer
+	if err := GenerateTemplateFromDir(parseToken(t), "TokenABI.c", &b, EncodeC, dir); err != nil {
+		t.Fatalf("Unexpected error in template generation: %v", err)
+	}
+	want := `TRANSFER_ADDR_U64 0x73563776 [0x76, 0x37, 0x56, 0x73]
+  to UniversalAddressABI | UniversalAddressABI | qtumstack.Address | UniversalAddress | UniversalAddressABI | UniversalAddress
+  amount uint64_t | uint64_t | uint64 | int | u64 | bigint
+  outputs [ok]
+TRANSFER_ADDR_U64_U8ARR 0xdb7542a2 [0xa2, 0x42, 0x75, 0xdb]
+  to UniversalAddressABI | UniversalAddressABI | qtumstack.Address | UniversalAddress | UniversalAddressABI | UniversalAddress
+  amount uint64_t | uint64_t | uint64 | int | u64 | bigint
+  data uint8_t* | std::vector<uint8_t> | []uint8 | List[int] | Vec<u8> | number[] dynamic uint8
+  outputs []
+ADJUST 0xe9e721f2 [0xf2, 0x21, 0xe7, 0xe9]
+  key uint8_t* | std::array<uint8_t, 4> | [4]uint8 | List[int] | [u8; 4] | number[] fixed uint8
+  delta int32_t | int32_t | int32 | int | i32 | number
+  who UniversalAddressABI* | std::vector<UniversalAddressABI> | []qtumstack.Address | List[UniversalAddress] | Vec<UniversalAddressABI> | UniversalAddress[] dynamic uniaddress
+  outputs [keys, total, list]
+PING 0x5a41ae21 [0x21, 0xae, 0x41, 0x5a]
+  outputs []
+ADMIN|MINTER abc Token token_abi
+  a
+
+  b
+`
+	if b.String() != want {
+		t.Errorf("Unexpected template output, got:\n%v", b.String())
+	}
+}
+
+func TestTemplateFuncErrors(t *testing.T) {
+	var funcInputs = []struct {
+		template string
+		err      string
+	}{
+		{`{{range .Functions}}{{range .Inputs}}{{langType "cobol" .}}{{end}}{{end}}`, "unknown language cobol"},
+		{`{{range .Functions}}{{range .Inputs}}{{langType "go" .}}{{end}}{{end}}`, "the caller context parameter @sender has no go type"},
+	}
+	for _, input := range funcInputs {
+		dir := t.TempDir()
+		if err := ioutil.WriteFile(filepath.Join(dir, "ABI.c.tmpl"), []byte(input.template), 0666); err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		var b bytes.Buffer
+		err := GenerateTemplateFromDir(parseToken(t), "TokenABI.c", &b, EncodeC, dir)
+		if err == nil || !strings.Contains(err.Error(), input.err) {
+			t.Errorf("Expected an error containing %q, got: %v", input.err, err)
+		}
+	}
+}
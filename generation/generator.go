@@ -0,0 +1,152 @@
+package generation
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qtumproject/simple-abi/definitions"
+)
+
+// File is a file generated for a contract
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// Options are the options of a generator run
+type Options struct {
+	// TemplateDir overrides built-in templates, see GenerateTemplateFromDir
+	TemplateDir string
+}
+
+// Generator generates an artifact, such as the encoding code, for contracts in one or more languages
+type Generator interface {
+	// Name is the name of the artifact, e.g. "encoding", unique among the generators of a language
+	Name() string
+	// Languages lists the --lang values the generator is available for
+	Languages() []string
+	// Generate returns the files generated for a contract in language
+	Generate(builder definitions.QInterfaceBuilder, language string, options Options) ([]File, error)
+}
+
+// registry holds the registered generators by language and name
+var registry = map[string]map[string]Generator{}
+
+// Register makes a generator available for its languages, it fails when one of the languages already
+// has a generator of the same name
+func Register(generator Generator) error {
+	for _, language := range generator.Languages() {
+		if _, exists := registry[language][generator.Name()]; exists {
+			return fmt.Errorf("a %v generator is already registered for %v", generator.Name(), language)
+		}
+	}
+	for _, language := range generator.Languages() {
+		if registry[language] == nil {
+			registry[language] = map[string]Generator{}
+		}
+		registry[language][generator.Name()] = generator
+	}
+	return nil
+}
+
+// Lookup returns the generator called name for language. Without a registered one it falls back to the
+// simpleabi-gen-<name> plugin on PATH, which is given the language and can generate any it supports
+func Lookup(language string, name string) (Generator, error) {
+	if generator, ok := registry[language][name]; ok {
+		return generator, nil
+	}
+	if generator, ok := lookupPlugin(name); ok {
+		return generator, nil
+	}
+	if _, ok := registry[language]; !ok {
+		return nil, fmt.Errorf("unknown language %v, select one of: %v", language, strings.Join(Languages(), ", "))
+	}
+	return nil, fmt.Errorf("no %v generator for %v and no %v%v plugin on PATH, select one of: %v",
+		name, language, PluginPrefix, name, strings.Join(Generators(language), ", "))
+}
+
+// Languages lists the languages with registered generators in alphabetical order
+func Languages() []string {
+	var languages []string
+	for language := range registry {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+// Generators lists the names of the generators registered for language in alphabetical order
+func Generators(language string) []string {
+	var names []string
+	for name := range registry[language] {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// templateGenerator generates a file per template in every language, named after the contract followed
+// by the template name
+type templateGenerator struct {
+	name      string
+	templates map[string][]string
+}
+
+func (g templateGenerator) Name() string {
+	return g.name
+}
+
+func (g templateGenerator) Languages() []string {
+	var languages []string
+	for language := range g.templates {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	return languages
+}
+
+func (g templateGenerator) Generate(builder definitions.QInterfaceBuilder, language string, options Options) ([]File, error) {
+	templates, ok := g.templates[language]
+	if !ok {
+		return nil, fmt.Errorf("no %v generator for %v", g.name, language)
+	}
+	var files []File
+	for _, templateName := range templates {
+		name := strings.TrimSuffix(builder.ContractName, ".abi") + templateName
+		var buf bytes.Buffer
+		if err := GenerateTemplateFromDir(builder, name, &buf, templateName, options.TemplateDir); err != nil {
+			return nil, err
+		}
+		files = append(files, File{Name: name, Content: buf.Bytes()})
+	}
+	return files, nil
+}
+
+func init() {
+	builtins := []templateGenerator{
+		{"encoding", map[string][]string{
+			"c":      {EncodeC, EncodeH},
+			"cpp":    {EncodeCpp},
+			"go":     {EncodeGo},
+			"python": {EncodePython},
+			"rust":   {EncodeRust},
+			"ts":     {EncodeTypeScript},
+		}},
+		{"decoding", map[string][]string{
+			"c":    {DecodeC, DecodeH},
+			"cpp":  {DecodeCpp},
+			"rust": {DecodeRust},
+		}},
+		{"storage", map[string][]string{
+			"c": {StorageC, StorageH},
+		}},
+	}
+	for _, generator := range builtins {
+		// the built-in generators have distinct names, this only fails when one is added twice
+		if err := Register(generator); err != nil {
+			panic(err)
+		}
+	}
+}
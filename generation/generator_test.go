@@ -0,0 +1,85 @@
+package generation
+
+import (
+	"testing"
+
+	"github.com/qtumproject/simple-abi/definitions"
+)
+
+type constGenerator struct{}
+
+func (constGenerator) Name() string {
+	return "constants"
+}
+
+func (constGenerator) Languages() []string {
+	return []string{"c", "test"}
+}
+
+func (constGenerator) Generate(builder definitions.QInterfaceBuilder, language string, options Options) ([]File, error) {
+	var content []byte
+	for _, constant := range builder.Constants {
+		content = append(content, constant.Name+"\n"...)
+	}
+	return []File{{Name: "constants.txt", Content: content}}, nil
+}
+
+func TestRegister(t *testing.T) {
+	if err := Register(constGenerator{}); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	defer func() {
+		delete(registry["c"], "constants")
+		delete(registry, "test")
+	}()
+
+	generator, err := Lookup("test", "constants")
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	files, err := generator.Generate(parseToken(t), "test", Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != "constants.txt" || string(files[0].Content) != "KEY_LEN\nFLOOR\n" {
+		t.Errorf("Unexpected files generated: %+v", files)
+	}
+
+	err = Register(constGenerator{})
+	if err == nil || err.Error() != "a constants generator is already registered for c" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestLookupErrors(t *testing.T) {
+	_, err := Lookup("cobol", "encoding")
+	if err == nil || err.Error() != "unknown language cobol, select one of: c, cpp, go, python, rust, ts" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	_, err = Lookup("go", "decoding")
+	if err == nil || err.Error() != "no decoding generator for go and no simpleabi-gen-decoding plugin on PATH, select one of: encoding" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestTemplateGenerator(t *testing.T) {
+	generator, err := Lookup("c", "decoding")
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	files, err := generator.Generate(parseToken(t), "c", Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if len(files) != 2 || files[0].Name != "TokenDispatcher.c" || files[1].Name != "TokenDispatcher.h" {
+		t.Fatalf("Unexpected files generated: %+v", files)
+	}
+	for _, file := range files {
+		if len(file.Content) == 0 {
+			t.Errorf("Expected %v to have content", file.Name)
+		}
+	}
+	if _, err := generator.Generate(parseToken(t), "go", Options{}); err == nil {
+		t.Errorf("Expected an error generating decoding for go")
+	}
+}
@@ -0,0 +1,43 @@
+package generation
+
+// goClientTemplateImpl is a template used for generation of the Go package calling a deployed contract
+const goClientTemplateImpl = `{{ $contractName := .ContractName }}{{.GenFileHeaderGo}}
+// Package {{.GoPackage}} calls the functions of a deployed {{$contractName}} contract, encoding call data
+// and decoding results with the stack package of simpleabi.
+{{.GenPackageGo}}
+
+import (
+	qtumstack "github.com/qtumproject/simple-abi/stack"
+)
+{{if .Constants}}
+// Constants
+const (
+{{range .Constants}}	{{.GenConstGo}}
+{{end}})
+{{end}}
+// Function IDs, pushed on top of the inputs of a call
+const (
+{{range .Functions}}	{{.GoName}}Selector uint32 = {{.GenHashedFuncIdentifier $contractName}}
+{{end}})
+
+// Functions describes the functions of the contract
+var Functions = []qtumstack.Function{
+{{range .Functions}}	{{.GenFunctionGo $contractName}}
+{{end}}}
+
+// {{.GoTypeName}} calls the functions of a deployed {{$contractName}} contract through a Caller
+type {{.GoTypeName}} struct {
+	caller qtumstack.Caller
+}
+
+// New{{$contractName}} returns bindings calling the contract through caller
+func New{{$contractName}}(caller qtumstack.Caller) *{{.GoTypeName}} {
+	return &{{.GoTypeName}}{caller: caller}
+}
+{{range .Functions}}
+{{.GenDocGo}}{{.GenMethodGo $.GoTypeName}}
+
+{{.GenEncodeGo}}
+
+{{.GenDecodeGo}}
+{{end}}`
@@ -0,0 +1,159 @@
+package generation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/simple-abi/definitions"
+	"github.com/qtumproject/simple-abi/parser"
+)
+
+// TestGoRoundTrip calls the C dispatcher of testdata/golden/Token.abi through the generated Go bindings,
+// see testdata/roundtrip/caller. The caller and the bindings are built as a module of their own in a
+// temporary directory, which uses the stack package of this checkout
+func TestGoRoundTrip(t *testing.T) {
+	dispatcher := buildDispatcher(t)
+	root, err := filepath.Abs("..")
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	dir := t.TempDir()
+	// the go.mod of this checkout with its go line and requires, whichever they are, covers the
+	// dependencies of the caller, which only adds simple-abi itself
+	rootMod, err := ioutil.ReadFile(filepath.Join(root, "go.mod"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	goMod := strings.Replace(string(rootMod), "module github.com/qtumproject/simple-abi", "module roundtrip", 1) +
+		"\nrequire github.com/qtumproject/simple-abi v0.0.0\n\nreplace github.com/qtumproject/simple-abi => " + root + "\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, "go.mod"), []byte(goMod), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	for _, file := range []struct{ from, to string }{
+		{filepath.Join(root, "go.sum"), "go.sum"},
+		{filepath.Join("testdata", "roundtrip", "caller", "main.go"), "main.go"},
+	} {
+		content, err := ioutil.ReadFile(file.from)
+		if err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		if err := ioutil.WriteFile(filepath.Join(dir, file.to), content, 0666); err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+	}
+	if err := os.Mkdir(filepath.Join(dir, "token"), 0777); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	generate(t, parseToken(t), filepath.Join(dir, "token", "TokenClient.go"), EncodeGo)
+
+	run := exec.Command("go", "run", ".", dispatcher)
+	run.Dir = dir
+	// the go.sum of this checkout covers the caller, so nothing needs to be downloaded or rewritten
+	run.Env = append(os.Environ(), "GOFLAGS=-mod=readonly")
+	out, err := run.CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unexpected error running the caller: %v\n%s", err, out)
+	}
+	checkRoundTrip(t, out)
+}
+
+func TestGoNames(t *testing.T) {
+	var b bytes.Buffer
+	builder := definitions.QInterfaceBuilder{
+		ContractName: "Func",
+		Functions: []definitions.QFunc{
+			definitions.QFunc{FuncName: "transfer", Inputs: []definitions.QType{
+				definitions.QType{Type: "uint8", TypeName: "range"},
+				definitions.QType{Type: "uint8", TypeName: "error"},
+				definitions.QType{Type: "uint8", TypeName: "nil"},
+			}},
+		},
+	}
+	if err := GenerateTemplate(builder, "FuncClient.go", &b, EncodeGo); err != nil {
+		t.Fatalf("Unexpected error in template generation of FuncClient.go: %v", err)
+	}
+	for _, want := range []string{"package func_\n", "func (__c *Func) Transfer(range_ uint8, error_ uint8, nil_ uint8) error {"} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected the Go bindings to contain %q, got %v", want, b.String())
+		}
+	}
+
+	var collisions = []struct {
+		functions []string
+		constants []string
+		err       string
+	}{
+		{[]string{"transfer", "Transfer"}, nil, "function transfer and function Transfer both become Transfer in the Go bindings"},
+		{[]string{"transfer"}, []string{"TransferSelector"}, "constant TransferSelector and function transfer both become TransferSelector in the Go bindings"},
+		{nil, []string{"Functions"}, "the Functions variable and constant Functions both become Functions in the Go bindings"},
+	}
+	for _, collision := range collisions {
+		builder.Functions = nil
+		builder.Constants = nil
+		for _, name := range collision.functions {
+			builder.Functions = append(builder.Functions, definitions.QFunc{FuncName: name})
+		}
+		for _, name := range collision.constants {
+			builder.Constants = append(builder.Constants, definitions.QConst{Name: name, Type: "uint8", Value: "1"})
+		}
+		err := GenerateTemplate(builder, "FuncClient.go", &b, EncodeGo)
+		if err == nil || !strings.Contains(err.Error(), collision.err) {
+			t.Errorf("Expected an error containing %q, got: %v", collision.err, err)
+		}
+	}
+}
+
+// buildDispatcher builds the C dispatcher of testdata/golden/Token.abi against the mock runtime in testdata/roundtrip
+// and returns the path of the executable, skipping the test without gcc
+func buildDispatcher(t *testing.T) string {
+	if _, err := exec.LookPath("gcc"); err != nil {
+		t.Skip("gcc is needed to build the C dispatcher")
+	}
+	builder := parseToken(t)
+	dir := t.TempDir()
+	generate(t, builder, filepath.Join(dir, "TokenDispatcher.c"), DecodeC)
+	generate(t, builder, filepath.Join(dir, "TokenDispatcher.h"), DecodeH)
+
+	dispatcher := filepath.Join(dir, "dispatcher")
+	gcc := exec.Command("gcc", "-I", filepath.Join("testdata", "roundtrip"), "-I", dir, "-o", dispatcher,
+		filepath.Join(dir, "TokenDispatcher.c"), filepath.Join("testdata", "roundtrip", "runtime.c"))
+	if out, err := gcc.CombinedOutput(); err != nil {
+		t.Fatalf("Unexpected error building the dispatcher: %v\n%s", err, out)
+	}
+	return dispatcher
+}
+
+// checkRoundTrip compares the output of a caller to testdata/roundtrip/expected.txt
+func checkRoundTrip(t *testing.T, out []byte) {
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "roundtrip", "expected.txt"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("Unexpected round trip, got:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func parseToken(t *testing.T) definitions.QInterfaceBuilder {
+	builders, err := parser.Parse(filepath.Join("testdata", "golden", "Token.abi"), false)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	return builders[0]
+}
+
+// generate writes the code generated from the built-in template called templateName to path
+func generate(t *testing.T, builder definitions.QInterfaceBuilder, path string, templateName string) {
+	var b bytes.Buffer
+	if err := GenerateTemplate(builder, filepath.Base(path), &b, templateName); err != nil {
+		t.Fatalf("Unexpected error in template generation of %v: %v", path, err)
+	}
+	if err := ioutil.WriteFile(path, b.Bytes(), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+}
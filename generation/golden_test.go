@@ -0,0 +1,105 @@
+package generation
+
+import (
+	"bytes"
+	"flag"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	"github.com/qtumproject/simple-abi/parser"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files with the generated output, see go test ./generation -update")
+
+// goldenInputs are the .abi files in testdata/golden, which are run through every registered generator. Their
+// contracts use every type, modifier and attribute. The pattern is valid, so Glob can't fail
+var goldenInputs, _ = filepath.Glob(filepath.Join("testdata", "golden", "*.abi"))
+
+// TestGolden compares the files every generator generates for goldenInputs to
+// testdata/golden/<contract>/<language>/<file>.golden
+func TestGolden(t *testing.T) {
+	for _, input := range goldenInputs {
+		builders, err := parser.Parse(input, false)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %v: %v", input, err)
+		}
+		for _, builder := range builders {
+			if builder.IsInterface {
+				continue
+			}
+			dir := filepath.Join("testdata", "golden", builder.ContractName)
+			generated := map[string]bool{}
+			for _, language := range Languages() {
+				for _, name := range Generators(language) {
+					generator, err := Lookup(language, name)
+					if err != nil {
+						t.Fatalf("Unexpected error occurred: %v", err)
+					}
+					files, err := generator.Generate(builder, language, Options{})
+					if err != nil {
+						t.Errorf("Unexpected error generating %v %v for %v: %v", language, name, builder.ContractName, err)
+						continue
+					}
+					for _, file := range files {
+						path := filepath.Join(dir, language, file.Name+".golden")
+						checkGoldenFile(t, path, file.Content)
+						generated[path] = true
+					}
+				}
+			}
+			checkStaleGoldenFiles(t, dir, generated)
+		}
+	}
+}
+
+// checkGoldenFile compares got to the golden file at path, or rewrites the file with -update
+func checkGoldenFile(t *testing.T, path string, got []byte) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0777); err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		if err := ioutil.WriteFile(path, got, 0666); err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		return
+	}
+	want, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		t.Errorf("Missing golden file %v, run go test ./generation -update to create it", path)
+		return
+	}
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Errorf("Generated output differs from %v, run go test ./generation -update to accept it (-want +got):\n%v",
+			path, cmp.Diff(strings.Split(string(want), "\n"), strings.Split(string(got), "\n")))
+	}
+}
+
+// checkStaleGoldenFiles reports golden files under dir which nothing generates anymore, or removes them with -update
+func checkStaleGoldenFiles(t *testing.T, dir string, generated map[string]bool) {
+	t.Helper()
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if os.IsNotExist(err) && path == dir {
+			return nil
+		}
+		if err != nil || info.IsDir() || generated[path] {
+			return err
+		}
+		if *update {
+			return os.Remove(path)
+		}
+		t.Errorf("Golden file %v isn't generated anymore, run go test ./generation -update to remove it", path)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+}
@@ -0,0 +1,97 @@
+package generation
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/qtumproject/simple-abi/definitions"
+)
+
+// PluginPrefix is the prefix of plugin executables: --generate name runs simpleabi-gen-<name> from PATH when
+// no built-in generator is called name
+const PluginPrefix = "simpleabi-gen-"
+
+// PluginRequest is written as JSON to the standard input of a plugin
+type PluginRequest struct {
+	// Version is definitions.IRVersion, plugins should fail on versions they don't know
+	Version int `json:"version"`
+	// Language is the --lang value
+	Language string                 `json:"language"`
+	Contract definitions.IRContract `json:"contract"`
+}
+
+// PluginResponse is read as JSON from the standard output of a plugin
+type PluginResponse struct {
+	Files []PluginFile `json:"files"`
+	// Error is reported instead of writing any files when it is set
+	Error string `json:"error,omitempty"`
+}
+
+// PluginFile is a file generated by a plugin, its name is relative to the output directory
+type PluginFile struct {
+	Name    string `json:"name"`
+	Content string `json:"content"`
+}
+
+// pluginGenerator runs an executable implementing the plugin protocol
+type pluginGenerator struct {
+	name string
+	path string
+}
+
+// lookupPlugin returns the generator running simpleabi-gen-<name> when it is found on PATH
+func lookupPlugin(name string) (Generator, bool) {
+	path, err := exec.LookPath(PluginPrefix + name)
+	if err != nil {
+		return nil, false
+	}
+	return pluginGenerator{name, path}, true
+}
+
+func (g pluginGenerator) Name() string {
+	return g.name
+}
+
+// Languages is empty as a plugin decides which languages it supports itself
+func (g pluginGenerator) Languages() []string {
+	return nil
+}
+
+func (g pluginGenerator) Generate(builder definitions.QInterfaceBuilder, language string, options Options) ([]File, error) {
+	var request, stdout, stderr bytes.Buffer
+	// signatures contain "->", which is easier to read unescaped
+	encoder := json.NewEncoder(&request)
+	encoder.SetEscapeHTML(false)
+	if err := encoder.Encode(PluginRequest{definitions.IRVersion, language, builder.IR()}); err != nil {
+		return nil, err
+	}
+	cmd := exec.Command(g.path)
+	cmd.Stdin = &request
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("plugin %v failed: %v: %v", g.path, err, strings.TrimSpace(stderr.String()))
+	}
+
+	var response PluginResponse
+	if err := json.Unmarshal(stdout.Bytes(), &response); err != nil {
+		return nil, fmt.Errorf("plugin %v returned an invalid response: %v", g.path, err)
+	}
+	if response.Error != "" {
+		return nil, fmt.Errorf("plugin %v: %v", g.path, response.Error)
+	}
+	var files []File
+	for _, file := range response.Files {
+		// plugins only write to the output directory
+		name := filepath.Clean(filepath.FromSlash(file.Name))
+		if file.Name == "" || filepath.IsAbs(name) || name == ".." || strings.HasPrefix(name, ".."+string(filepath.Separator)) {
+			return nil, fmt.Errorf("plugin %v returned an invalid file name %q", g.path, file.Name)
+		}
+		files = append(files, File{Name: name, Content: []byte(file.Content)})
+	}
+	return files, nil
+}
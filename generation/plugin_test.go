@@ -0,0 +1,83 @@
+package generation
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/simple-abi/definitions"
+)
+
+// TestPluginRequest checks the JSON plugins receive for testdata/golden/Token.abi against testdata/plugin/Token.json,
+// any difference is a change of the IR plugins rely on
+func TestPluginRequest(t *testing.T) {
+	var request bytes.Buffer
+	encoder := json.NewEncoder(&request)
+	encoder.SetEscapeHTML(false)
+	encoder.SetIndent("", "  ")
+	if err := encoder.Encode(PluginRequest{definitions.IRVersion, "c", parseToken(t).IR()}); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	checkGoldenFile(t, filepath.Join("testdata", "plugin", "Token.json"), request.Bytes())
+}
+
+// TestPlugin runs the reference plugin in testdata/plugin/simpleabi-gen-markdown
+func TestPlugin(t *testing.T) {
+	dir := t.TempDir()
+	build := exec.Command("go", "build", "-o", filepath.Join(dir, PluginPrefix+"markdown"), "./testdata/plugin/simpleabi-gen-markdown")
+	if out, err := build.CombinedOutput(); err != nil {
+		t.Fatalf("Unexpected error building the plugin: %v\n%s", err, out)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+
+	generator, err := Lookup("c", "markdown")
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	files, err := generator.Generate(parseToken(t), "c", Options{})
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if len(files) != 1 || files[0].Name != filepath.Join("docs", "Token.md") {
+		t.Fatalf("Unexpected files generated: %+v", files)
+	}
+	checkGoldenFile(t, filepath.Join("testdata", "plugin", "Token.md"), files[0].Content)
+}
+
+func TestPluginErrors(t *testing.T) {
+	if _, err := exec.LookPath("sh"); err != nil {
+		t.Skip("sh is needed to run the test plugins")
+	}
+	tests := []struct {
+		name   string
+		script string
+		err    string
+	}{
+		{"failing", "echo 'no luck' >&2; exit 3", "failed: exit status 3: no luck"},
+		{"garbage", "echo 'hello'", "returned an invalid response"},
+		{"refusing", `echo '{"error": "language c is not supported"}'`, ": language c is not supported"},
+		{"escaping", `echo '{"files": [{"name": "../outside.c", "content": ""}]}'`, `returned an invalid file name "../outside.c"`},
+		{"absolute", `echo '{"files": [{"name": "/etc/outside.c", "content": ""}]}'`, `returned an invalid file name "/etc/outside.c"`},
+	}
+	dir := t.TempDir()
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	for _, test := range tests {
+		script := "#!/bin/sh\ncat > /dev/null\n" + test.script + "\n"
+		if err := ioutil.WriteFile(filepath.Join(dir, PluginPrefix+test.name), []byte(script), 0777); err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		generator, err := Lookup("c", test.name)
+		if err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		_, err = generator.Generate(parseToken(t), "c", Options{})
+		if err == nil || !strings.Contains(err.Error(), test.err) {
+			t.Errorf("Expected an error containing %q from the %v plugin, got: %v", test.err, test.name, err)
+		}
+	}
+}
@@ -0,0 +1,147 @@
+package generation
+
+// pythonStackRuntime encodes and decodes the call stack in pure Python, in the wire format of the stack package:
+// items from the bottom to the top, every item followed by its length as a little endian uint32
+const pythonStackRuntime = `@dataclass(frozen=True)
+class UniversalAddress:
+    """UniversalAddressABI: the address version followed by 32 bytes of address data"""
+
+    version: int = 0
+    data: bytes = bytes(32)
+
+
+@dataclass(frozen=True)
+class CallOptions:
+    """options of a single call, value is the amount of coins sent along with it and a gas_limit of 0
+    leaves the gas limit to the transport"""
+
+    value: int = 0
+    gas_limit: int = 0
+
+
+_ADDRESS_SIZE = 36
+
+
+def _pack_address(address: UniversalAddress) -> bytes:
+    if len(address.data) != 32:
+        raise ValueError("address data has to be 32 bytes, got %d" % len(address.data))
+    return struct.pack("<I", address.version) + bytes(address.data)
+
+
+def _unpack_address(item: bytes) -> UniversalAddress:
+    return UniversalAddress(struct.unpack_from("<I", item)[0], bytes(item[4:_ADDRESS_SIZE]))
+
+
+class Stack:
+    """call stack as Qtum x86 contracts receive their inputs on and push their outputs onto.
+    Integers are little endian, arrays are their elements back to back"""
+
+    def __init__(self) -> None:
+        self.items: List[bytes] = []
+
+    @classmethod
+    def decode(cls, data: bytes) -> "Stack":
+        """parses call data or the data returned by a call"""
+        stack = cls()
+        end = len(data)
+        while end > 0:
+            if end < 4:
+                raise ValueError("stack: %d bytes left before the first item, expected a length" % end)
+            size = struct.unpack_from("<I", data, end - 4)[0]
+            if size > end - 4:
+                raise ValueError("stack: item of %d bytes does not fit in the %d bytes before it" % (size, end - 4))
+            stack.items.insert(0, bytes(data[end - 4 - size:end - 4]))
+            end -= 4 + size
+        return stack
+
+    def encode(self) -> bytes:
+        return b"".join(item + struct.pack("<I", len(item)) for item in self.items)
+
+    def push(self, item: bytes) -> None:
+        self.items.append(bytes(item))
+
+    def pop(self) -> bytes:
+        if not self.items:
+            raise ValueError("stack: pop from an empty stack")
+        return self.items.pop()
+
+    def push_int(self, fmt: str, value: int) -> None:
+        self.push(struct.pack("<" + fmt, value))
+
+    def pop_int(self, fmt: str) -> int:
+        return struct.unpack("<" + fmt, self._pop_exact(struct.calcsize(fmt)))[0]
+
+    def push_address(self, address: UniversalAddress) -> None:
+        self.push(_pack_address(address))
+
+    def pop_address(self) -> UniversalAddress:
+        return _unpack_address(self._pop_exact(_ADDRESS_SIZE))
+
+    def push_ints(self, fmt: str, values: Sequence[int], length: Optional[int] = None) -> None:
+        _check_length(values, length)
+        self.push(struct.pack("<%d%s" % (len(values), fmt), *values))
+
+    def pop_ints(self, fmt: str, length: Optional[int] = None) -> List[int]:
+        item = self._pop_array(struct.calcsize(fmt), length)
+        return list(struct.unpack("<%d%s" % (len(item) // struct.calcsize(fmt), fmt), item))
+
+    def push_addresses(self, values: Sequence[UniversalAddress], length: Optional[int] = None) -> None:
+        _check_length(values, length)
+        self.push(b"".join(_pack_address(value) for value in values))
+
+    def pop_addresses(self, length: Optional[int] = None) -> List[UniversalAddress]:
+        item = self._pop_array(_ADDRESS_SIZE, length)
+        return [_unpack_address(item[i:i + _ADDRESS_SIZE]) for i in range(0, len(item), _ADDRESS_SIZE)]
+
+    def _pop_exact(self, size: int) -> bytes:
+        item = self.pop()
+        if len(item) != size:
+            raise ValueError("stack: item of %d bytes, expected %d" % (len(item), size))
+        return item
+
+    def _pop_array(self, size: int, length: Optional[int]) -> bytes:
+        if length is not None:
+            return self._pop_exact(size * length)
+        item = self.pop()
+        if len(item) % size != 0:
+            raise ValueError("stack: item of %d bytes is not an array of %d byte elements" % (len(item), size))
+        return item
+
+
+def _check_length(values: Sized, length: Optional[int]) -> None:
+    if length is not None and len(values) != length:
+        raise ValueError("expected %d elements, got %d" % (length, len(values)))
+`
+
+// pythonClientTemplateImpl is a template used for generation of the Python module calling a deployed contract
+const pythonClientTemplateImpl = `{{ $contractName := .ContractName }}{{.GenFileHeaderPython}}"""Calls the functions of a deployed {{$contractName}} contract, see the {{$contractName}} class"""
+
+import struct
+import warnings
+from dataclasses import dataclass
+from typing import Callable, List, Optional, Sequence, Sized
+
+` + pythonStackRuntime + `
+
+{{if .Constants}}# Constants
+{{range .Constants}}{{.GenConstPython}}
+{{end}}
+{{end}}# Function IDs, pushed on top of the inputs of a call
+{{range .Functions}}ID_{{$contractName}}_{{.CName}} = {{.GenHashedFuncIdentifier $contractName}}
+{{end}}
+
+{{range .Functions}}{{.GenResultDataclassPython}}{{.GenEncodePython $contractName}}
+
+
+{{.GenDecodePython}}
+
+
+{{end}}class {{$contractName}}:
+    """calls the functions of a deployed {{$contractName}} contract through call, a function sending call data
+    along with its CallOptions and returning the data the contract returned"""
+
+    def __init__(self, call: Callable[[bytes, CallOptions], bytes]) -> None:
+        self._call = call
+{{range .Functions}}
+    {{.GenMethodPython}}
+{{end}}`
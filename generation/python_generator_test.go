@@ -0,0 +1,77 @@
+package generation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/simple-abi/parser"
+)
+
+// TestPythonRoundTrip calls the C dispatcher of testdata/golden/Token.abi through the generated Python module,
+// see testdata/roundtrip/caller.py
+func TestPythonRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is needed to run the caller")
+	}
+	dispatcher := buildDispatcher(t)
+	dir := t.TempDir()
+	generate(t, parseToken(t), filepath.Join(dir, "TokenClient.py"), EncodePython)
+
+	out, err := exec.Command("python3", "-u", filepath.Join("testdata", "roundtrip", "caller.py"), dir, dispatcher).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unexpected error running the caller: %v\n%s", err, out)
+	}
+	checkRoundTrip(t, out)
+}
+
+// TestPythonCompiles compiles the Python generated for every golden contract
+func TestPythonCompiles(t *testing.T) {
+	if _, err := exec.LookPath("python3"); err != nil {
+		t.Skip("python3 is needed to compile the generated Python")
+	}
+	modules, err := filepath.Glob(filepath.Join("testdata", "golden", "*", "python", "*.py.golden"))
+	if err != nil || len(modules) == 0 {
+		t.Fatalf("Expected golden Python modules, got %v: %v", modules, err)
+	}
+	for _, module := range modules {
+		compile := "import sys; compile(open(sys.argv[1]).read(), sys.argv[1], 'exec')"
+		if out, err := exec.Command("python3", "-c", compile, module).CombinedOutput(); err != nil {
+			t.Errorf("Unexpected error compiling %v: %v\n%s", module, err, out)
+		}
+	}
+}
+
+func TestPythonKeywords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Keywords.abi")
+	abi := ":name=Keywords\nfrom:uniaddress is:uint8[] lambda:fn -> global:uint8 pass:int64\n" +
+		"self:uint8 Stack:uint8 _options:uint8 shadow:fn -> void\n"
+	if err := ioutil.WriteFile(path, []byte(abi), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	builders, err := parser.Parse(path, false)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	var b bytes.Buffer
+	if err := GenerateTemplate(builders[0], "KeywordsClient.py", &b, EncodePython); err != nil {
+		t.Fatalf("Unexpected error in template generation: %v", err)
+	}
+	for _, want := range []string{
+		"    global_: int\n    pass_: int\n",
+		"def encode_lambda(from_: UniversalAddress, is_: List[int]) -> bytes:",
+		`    __s.push_ints("B", is_)`,
+		"    pass_ = __s.pop_int(\"q\")\n    global_ = __s.pop_int(\"B\")\n    return LambdaResult(global_, pass_)",
+		"    def lambda_(self, from_: UniversalAddress, is_: List[int], _options: Optional[CallOptions] = None) -> LambdaResult:",
+		"encode_lambda(from_, is_)",
+		"def encode_shadow(self_: int, Stack_: int, _options_: int) -> bytes:",
+		"    def shadow(self, self_: int, Stack_: int, _options_: int, _options: Optional[CallOptions] = None) -> None:",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected the generated module to contain %q, got:\n%v", want, b.String())
+		}
+	}
+}
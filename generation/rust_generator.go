@@ -0,0 +1,147 @@
+package generation
+
+// rustStackHelpers moves everything that is not an integer on and off the call stack as little endian bytes.
+// Fixed size arrays travel as exactly their length in elements, dynamic arrays as however many elements were pushed
+const rustStackHelpers = `// stack helpers, arrays and addresses travel as little endian bytes
+trait __Word: Sized {
+    const SIZE: usize;
+    fn write(&self, out: &mut Vec<u8>);
+    fn read(bytes: &[u8]) -> Self;
+}
+
+macro_rules! __word {
+    ($($t:ty),*) => {$(
+        impl __Word for $t {
+            const SIZE: usize = core::mem::size_of::<$t>();
+            fn write(&self, out: &mut Vec<u8>) {
+                out.extend_from_slice(&self.to_le_bytes());
+            }
+            fn read(bytes: &[u8]) -> Self {
+                let mut word = [0u8; core::mem::size_of::<$t>()];
+                word.copy_from_slice(bytes);
+                <$t>::from_le_bytes(word)
+            }
+        }
+    )*};
+}
+__word!(u8, u16, u32, u64, i8, i16, i32, i64);
+
+impl __Word for UniversalAddressABI {
+    const SIZE: usize = UniversalAddressABI::SIZE;
+    fn write(&self, out: &mut Vec<u8>) {
+        out.extend_from_slice(self.as_bytes());
+    }
+    fn read(bytes: &[u8]) -> Self {
+        UniversalAddressABI::from_bytes(bytes)
+    }
+}
+
+fn __push_item<T: __Word>(item: &T) {
+    let mut bytes = Vec::with_capacity(T::SIZE);
+    item.write(&mut bytes);
+    qtum::push_bytes(&bytes);
+}
+
+fn __push_words<T: __Word>(words: &[T]) {
+    let mut bytes = Vec::with_capacity(words.len() * T::SIZE);
+    for word in words {
+        word.write(&mut bytes);
+    }
+    qtum::push_bytes(&bytes);
+}
+
+fn __pop_item<T: __Word>() -> T {
+    let bytes = qtum::pop_bytes();
+    if bytes.len() != T::SIZE {
+        qtum::error("invalid item size");
+    }
+    T::read(&bytes)
+}
+
+fn __pop_words<T: __Word>() -> Vec<T> {
+    let bytes = qtum::pop_bytes();
+    if bytes.len() % T::SIZE != 0 {
+        qtum::error("invalid array size");
+    }
+    bytes.chunks(T::SIZE).map(T::read).collect()
+}
+
+fn __pop_array<T: __Word + Copy + Default, const N: usize>() -> [T; N] {
+    let words = __pop_words::<T>();
+    if words.len() != N {
+        qtum::error("invalid array length");
+    }
+    let mut array = [T::default(); N];
+    array.copy_from_slice(&words);
+    array
+}
+`
+
+// rustEncodingTemplateImpl is a template used for generation of the call wrappers in a .rs file
+const rustEncodingTemplateImpl = `{{ $contractName := .ContractName -}}
+{{.GenFileHeaderRust}}#![allow(dead_code, non_snake_case, non_upper_case_globals, non_camel_case_types)]
+
+use qtum::{CallOptions, CallResult, UniversalAddress, UniversalAddressABI};
+
+{{if .Constants}}// Constants
+{{range .Constants}}{{.GenConstRust}}
+{{end}}
+{{end}}// Function IDs
+{{range .Functions}}pub const ID_{{$contractName}}_{{.CName}}: u32 = {{.GenHashedFuncIdentifier $contractName}};
+{{end}}
+{{range .Functions}}{{.GenDocRust "" true}}{{.GenCallRust $contractName}}
+
+{{end}}` + rustStackHelpers
+
+// rustDecodingTemplateImpl is a template used for generation of the dispatcher trait and dispatch function in a .rs file
+const rustDecodingTemplateImpl = `{{ $contractName := .ContractName -}}
+{{.GenFileHeaderRust}}#![allow(dead_code, non_snake_case, non_upper_case_globals, non_camel_case_types)]
+
+use qtum::UniversalAddressABI;
+
+{{if .Constants}}// Constants
+{{range .Constants}}{{.GenConstRust}}
+{{end}}
+{{end}}// Function IDs
+{{range .Functions}}pub const ID_{{$contractName}}_{{.CName}}: u32 = {{.GenHashedFuncIdentifier $contractName}};
+{{end}}
+/// {{.RustTraitName}} is implemented by the contract, dispatch calls its methods
+pub trait {{.RustTraitName}} {
+{{range .Functions}}{{.GenDocRust "    " false}}    {{.GenTraitMethodRust}}
+{{end}}{{range .Roles}}    /// role_{{.}} returns the address holding the {{.}} role
+    fn role_{{.}}(&self) -> UniversalAddressABI;
+{{end}}}
+{{if .UsesReentrancyGuard}}
+// reentrancy guard, qtum::error reverts the storage writes of the failing call, so the lock
+// never outlives a call that errors while holding it
+const __LOCK_KEY: &[u8] = b"__{{$contractName}}_reentrancy_lock";
+
+fn __nonreentrant_enter() {
+    let mut locked = [0u8; 1];
+    qtum::load(__LOCK_KEY, &mut locked);
+    if locked[0] != 0 {
+        qtum::error("reentrant call");
+    }
+    qtum::store(__LOCK_KEY, &[1]);
+}
+
+fn __nonreentrant_exit() {
+    qtum::store(__LOCK_KEY, &[0]);
+}
+
+{{end}}
+/// dispatch pops the function ID and inputs of a call off the stack, calls the matching method
+/// of the contract and pushes its outputs
+pub fn dispatch<__Contract: {{.RustTraitName}}>(__contract: &mut __Contract) {
+    let __fn = qtum::pop32();
+    match __fn {
+{{range .Functions}}        ID_{{$contractName}}_{{.CName}} => {
+            {{.GenDispatchCodeRust}}
+        }
+{{end}}        _ => {
+            // fallback function / error
+        }
+    }
+}
+
+` + rustStackHelpers
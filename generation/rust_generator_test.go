@@ -0,0 +1,87 @@
+package generation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"testing"
+
+	"github.com/qtumproject/simple-abi/parser"
+)
+
+// buildQtumCrate builds the mock qtum crate of testdata/roundtrip/rust/qtum.rs into dir, skipping the test
+// when rustc is not installed
+func buildQtumCrate(t *testing.T, dir string) string {
+	if _, err := exec.LookPath("rustc"); err != nil {
+		t.Skip("rustc is needed to build the generated Rust")
+	}
+	crate := filepath.Join(dir, "libqtum.rlib")
+	rustc(t, "--crate-type", "rlib", "--crate-name", "qtum", "-o", crate, filepath.Join("testdata", "roundtrip", "rust", "qtum.rs"))
+	return "qtum=" + crate
+}
+
+func rustc(t *testing.T, args ...string) {
+	args = append([]string{"--edition", "2021", "-D", "warnings"}, args...)
+	if out, err := exec.Command("rustc", args...).CombinedOutput(); err != nil {
+		t.Fatalf("Unexpected error running rustc %v: %v\n%s", args, err, out)
+	}
+}
+
+// TestRustCompiles type checks the Rust generated for every golden contract against the mock qtum crate
+func TestRustCompiles(t *testing.T) {
+	dir := t.TempDir()
+	qtum := buildQtumCrate(t, dir)
+	for _, input := range goldenInputs {
+		builders, err := parser.Parse(input, false)
+		if err != nil {
+			t.Fatalf("Unexpected error parsing %v: %v", input, err)
+		}
+		for _, builder := range builders {
+			if builder.IsInterface {
+				continue
+			}
+			name := builder.ContractName
+			generate(t, builder, filepath.Join(dir, name+EncodeRust), EncodeRust)
+			generate(t, builder, filepath.Join(dir, name+DecodeRust), DecodeRust)
+			lib := filepath.Join(dir, name+".rs")
+			crate := "#![allow(non_snake_case)]\nmod " + name + "ABI;\nmod " + name + "Dispatcher;\n"
+			if err := ioutil.WriteFile(lib, []byte(crate), 0666); err != nil {
+				t.Fatalf("Unexpected error occurred: %v", err)
+			}
+			rustc(t, "--crate-type", "lib", "--emit", "metadata", "--extern", qtum, "--out-dir", dir, lib)
+		}
+	}
+}
+
+// TestRustRoundTrip calls the Token trait implemented in testdata/roundtrip/rust/main.rs through the generated
+// call wrappers, whose qtum::call dispatches in the same process
+func TestRustRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	qtum := buildQtumCrate(t, dir)
+	builder := parseToken(t)
+	generate(t, builder, filepath.Join(dir, "TokenABI.rs"), EncodeRust)
+	generate(t, builder, filepath.Join(dir, "TokenDispatcher.rs"), DecodeRust)
+	main, err := ioutil.ReadFile(filepath.Join("testdata", "roundtrip", "rust", "main.rs"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	// main.rs declares the generated files as modules, so it is built next to them
+	if err := ioutil.WriteFile(filepath.Join(dir, "main.rs"), main, 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+
+	program := filepath.Join(dir, "roundtrip")
+	rustc(t, "--extern", qtum, "-o", program, filepath.Join(dir, "main.rs"))
+	out, err := exec.Command(program).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unexpected error running the Rust round trip: %v\n%s", err, out)
+	}
+	want, err := ioutil.ReadFile(filepath.Join("testdata", "roundtrip", "rust", "expected.txt"))
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if !bytes.Equal(out, want) {
+		t.Errorf("Unexpected round trip, got:\n%s\nwant:\n%s", out, want)
+	}
+}
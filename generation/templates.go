@@ -0,0 +1,148 @@
+package generation
+
+import (
+	"bytes"
+	"fmt"
+	"go/format"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/qtumproject/simple-abi/definitions"
+)
+
+// TemplateExt is the extension of template files in a template directory
+const TemplateExt = ".tmpl"
+
+// Names of the built-in templates, named after the suffix of the files they generate. A template directory
+// overrides a template with a file called <name>.tmpl
+const (
+	EncodeC          = "ABI.c"
+	DecodeC          = "Dispatcher.c"
+	EncodeH          = "ABI.h"
+	DecodeH          = "Dispatcher.h"
+	StorageC         = "Storage.c"
+	StorageH         = "Storage.h"
+	EncodeRust       = "ABI.rs"
+	DecodeRust       = "Dispatcher.rs"
+	EncodeCpp        = "Client.hpp"
+	DecodeCpp        = "Impl.hpp"
+	EncodeGo         = "Client.go"
+	EncodePython     = "Client.py"
+	EncodeTypeScript = "Client.ts"
+)
+
+var builtinTemplates = map[string]string{
+	EncodeC:          cEncodingTemplateImpl,
+	DecodeC:          cDecodingTemplateImpl,
+	EncodeH:          headerEncodingTemplateImpl,
+	DecodeH:          headerDecodingTemplateImpl,
+	StorageC:         cStorageTemplateImpl,
+	StorageH:         headerStorageTemplateImpl,
+	EncodeRust:       rustEncodingTemplateImpl,
+	DecodeRust:       rustDecodingTemplateImpl,
+	EncodeCpp:        cppClientTemplateImpl,
+	DecodeCpp:        cppImplTemplateImpl,
+	EncodeGo:         goClientTemplateImpl,
+	EncodePython:     pythonClientTemplateImpl,
+	EncodeTypeScript: tsClientTemplateImpl,
+}
+
+// TemplateNames lists the names of the built-in templates in alphabetical order
+func TemplateNames() []string {
+	var names []string
+	for name := range builtinTemplates {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// BuiltinTemplate returns the text of the built-in template called name
+func BuiltinTemplate(name string) (string, error) {
+	text, ok := builtinTemplates[name]
+	if !ok {
+		return "", fmt.Errorf("unknown template %q", name)
+	}
+	return text, nil
+}
+
+// LoadTemplate returns the text of <templateDir>/<name>.tmpl when the file exists, and the built-in template
+// called name otherwise. An empty templateDir always selects the built-in template
+func LoadTemplate(name string, templateDir string) (string, error) {
+	builtin, err := BuiltinTemplate(name)
+	if err != nil || templateDir == "" {
+		return builtin, err
+	}
+	text, err := ioutil.ReadFile(filepath.Join(templateDir, name+TemplateExt))
+	if os.IsNotExist(err) {
+		return builtin, nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return string(text), nil
+}
+
+// CheckTemplateDir checks that templateDir is a directory and that every template file in it overrides
+// a built-in template, catching misspelled names that would silently be ignored
+func CheckTemplateDir(templateDir string) error {
+	files, err := ioutil.ReadDir(templateDir)
+	if err != nil {
+		return err
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(file.Name(), TemplateExt)
+		if _, ok := builtinTemplates[name]; ok || name == file.Name() {
+			continue
+		}
+		return fmt.Errorf("%v in %v doesn't override a built-in template", file.Name(), templateDir)
+	}
+	return nil
+}
+
+// GenerateTemplate takes in a QInterfaceBuilder and writes the file generated from the built-in template
+// called templateName to output, name being the name of the generated file
+func GenerateTemplate(builder definitions.QInterfaceBuilder, name string, output io.Writer, templateName string) error {
+	return GenerateTemplateFromDir(builder, name, output, templateName, "")
+}
+
+// GenerateTemplateFromDir is GenerateTemplate with the template overridden by the file of the same name
+// in templateDir, when there is one, see LoadTemplate
+func GenerateTemplateFromDir(builder definitions.QInterfaceBuilder, name string, output io.Writer, templateName string, templateDir string) error {
+	errMsg := "Error in " + templateName + " template generation: %v"
+	toParse, err := LoadTemplate(templateName, templateDir)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	templ, err := template.New(name).Funcs(TemplateFuncs()).Parse(toParse)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	if templateName == EncodeGo {
+		// Go code is gofmt'ed instead of aligning declarations in the template
+		var buf bytes.Buffer
+		if err = templ.Execute(&buf, builder); err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+		formatted, err := format.Source(buf.Bytes())
+		if err != nil {
+			return fmt.Errorf(errMsg, err)
+		}
+		_, err = output.Write(formatted)
+		return err
+	}
+
+	err = templ.Execute(output, builder)
+	if err != nil {
+		return fmt.Errorf(errMsg, err)
+	}
+
+	return nil
+}
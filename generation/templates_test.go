@@ -0,0 +1,73 @@
+package generation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestTemplateNames(t *testing.T) {
+	for _, name := range TemplateNames() {
+		if text, err := BuiltinTemplate(name); err != nil || text == "" {
+			t.Errorf("Expected a built-in template called %v, got %q, %v", name, text, err)
+		}
+	}
+	var b bytes.Buffer
+	err := GenerateTemplate(parseToken(t), "TokenABI.cc", &b, "ABI.cc")
+	if err == nil || err.Error() != `Error in ABI.cc template generation: unknown template "ABI.cc"` {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestTemplateDir(t *testing.T) {
+	dir := t.TempDir()
+	override := "// Copyright ACME\n{{$c := .ContractName}}{{range .Functions}}{{.CName}} {{.GenHashedFuncIdentifier $c}}\n{{end}}"
+	if err := ioutil.WriteFile(filepath.Join(dir, "ABI.c.tmpl"), []byte(override), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if err := CheckTemplateDir(dir); err != nil {
+		t.Errorf("Unexpected error occurred: %v", err)
+	}
+
+	builder := parseToken(t)
+	var b bytes.Buffer
+	if err := GenerateTemplateFromDir(builder, "TokenABI.c", &b, EncodeC, dir); err != nil {
+		t.Fatalf("Unexpected error in template generation: %v", err)
+	}
+	want := "// Copyright ACME\ntransfer__addr_u64 0x73563776\ntransfer__addr_u64_u8arr 0xdb7542a2\nadjust 0xe9e721f2\nping 0x5a41ae21\n"
+	if b.String() != want {
+		t.Errorf("Expected the override to be used, got:\n%v", b.String())
+	}
+
+	// templates without a file in the directory are the built-in ones
+	var fromDir, builtin bytes.Buffer
+	if err := GenerateTemplateFromDir(builder, "TokenABI.h", &fromDir, EncodeH, dir); err != nil {
+		t.Fatalf("Unexpected error in template generation: %v", err)
+	}
+	if err := GenerateTemplate(builder, "TokenABI.h", &builtin, EncodeH); err != nil {
+		t.Fatalf("Unexpected error in template generation: %v", err)
+	}
+	if fromDir.String() != builtin.String() {
+		t.Errorf("Expected the built-in template, got:\n%v", fromDir.String())
+	}
+
+	if err := ioutil.WriteFile(filepath.Join(dir, "ABI.cc.tmpl"), nil, 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if err := CheckTemplateDir(dir); err == nil || err.Error() != "ABI.cc.tmpl in "+dir+" doesn't override a built-in template" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestTemplateDirErrors(t *testing.T) {
+	dir := t.TempDir()
+	if err := ioutil.WriteFile(filepath.Join(dir, "Client.py.tmpl"), []byte("{{.NoSuchField}}"), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	var b bytes.Buffer
+	err := GenerateTemplateFromDir(parseToken(t), "TokenClient.py", &b, EncodePython, dir)
+	if err == nil {
+		t.Errorf("Expected an error executing a template with an unknown field")
+	}
+}
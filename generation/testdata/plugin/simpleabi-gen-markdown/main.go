@@ -0,0 +1,71 @@
+// Command simpleabi-gen-markdown is the reference generator plugin used by TestPlugin. It reads a plugin
+// request from its standard input and returns a Markdown page documenting the contract's functions
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/qtumproject/simple-abi/definitions"
+	"github.com/qtumproject/simple-abi/generation"
+)
+
+func main() {
+	var request generation.PluginRequest
+	if err := json.NewDecoder(os.Stdin).Decode(&request); err != nil {
+		fmt.Fprintf(os.Stderr, "invalid request: %v\n", err)
+		os.Exit(1)
+	}
+	var response generation.PluginResponse
+	if request.Version != definitions.IRVersion {
+		response.Error = fmt.Sprintf("unsupported IR version %v", request.Version)
+	} else {
+		response.Files = []generation.PluginFile{{
+			Name:    "docs/" + request.Contract.Name + ".md",
+			Content: markdown(request.Contract),
+		}}
+	}
+	if err := json.NewEncoder(os.Stdout).Encode(response); err != nil {
+		fmt.Fprintf(os.Stderr, "error writing the response: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+func markdown(contract definitions.IRContract) string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "# %v\n", contract.Name)
+	if version, ok := contract.Attributes["version"]; ok {
+		fmt.Fprintf(&b, "\nVersion %v\n", version)
+	}
+	for _, function := range contract.Functions {
+		fmt.Fprintf(&b, "\n## %v\n\n`%v` selector `%v`\n", function.Name, function.Signature, function.Selector)
+		if function.Doc != "" {
+			fmt.Fprintf(&b, "\n%v\n", function.Doc)
+		}
+		if function.Payable {
+			b.WriteString("\nPayable.\n")
+		}
+		if len(function.OnlyRoles) > 0 {
+			fmt.Fprintf(&b, "\nOnly callable by: %v.\n", strings.Join(function.OnlyRoles, ", "))
+		}
+		table(&b, "Input", function.Inputs)
+		table(&b, "Output", function.Outputs)
+	}
+	return b.String()
+}
+
+// table lists inputs or outputs, leaving out caller context parameters as callers don't pass them
+func table(b *strings.Builder, heading string, types []definitions.IRType) {
+	var rows []string
+	for _, typ := range types {
+		if !typ.Context {
+			rows = append(rows, fmt.Sprintf("| %v | `%v` | %v |", typ.Name, typ.Type, typ.Doc))
+		}
+	}
+	if len(rows) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "\n| %v | Type | Description |\n| --- | --- | --- |\n%v\n", heading, strings.Join(rows, "\n"))
+}
@@ -0,0 +1,166 @@
+/*
+ * mock Qtum runtime for TestReentrancyGuard, linked with the dispatcher generated for a Guard contract with a
+ * single nonreentrant function, mode:uint8 withdraw:fn:nonreentrant. Every call runs in a frame: qtumError
+ * leaves the innermost frame and reverts the storage writes made since it started, like a failing call on
+ * chain. Storage persists across calls, so a lock left behind by a failing call would reject the next one
+ */
+#include <setjmp.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <qtum.h>
+#include "GuardDispatcher.h"
+
+#define MAX_ITEMS 16
+#define MAX_ENTRIES 8
+#define MAX_FRAMES 4
+
+static struct {
+    uint8_t data[64];
+    size_t size;
+} items[MAX_ITEMS];
+static size_t itemCount = 0;
+
+typedef struct {
+    struct {
+        uint8_t key[64];
+        size_t keySize;
+        uint8_t value[64];
+        size_t size;
+    } entries[MAX_ENTRIES];
+    size_t count;
+} Storage;
+static Storage storage;
+
+static struct {
+    jmp_buf error;
+    Storage snapshot;
+    size_t itemCount;
+} frames[MAX_FRAMES];
+static size_t frameCount = 0;
+
+static QtumExec exec;
+const QtumExec* qtumExec = &exec;
+
+static void fail(const char* msg){
+    fprintf(stderr, "mock runtime: %s\n", msg);
+    exit(2);
+}
+
+void qtumError(const char* msg){
+    if(frameCount == 0){
+        fail(msg);
+    }
+    printf("error: %s\n", msg);
+    longjmp(frames[frameCount - 1].error, 1);
+}
+
+void qtumPush(const void* buffer, size_t size){
+    if(itemCount == MAX_ITEMS || size > sizeof(items[0].data)){
+        fail("stack overflow");
+    }
+    memcpy(items[itemCount].data, buffer, size);
+    items[itemCount].size = size;
+    itemCount++;
+}
+
+size_t qtumPeekSize(){
+    if(itemCount == 0){
+        qtumError("peek on an empty stack");
+    }
+    return items[itemCount - 1].size;
+}
+
+size_t qtumPop(void* buffer, size_t maxSize){
+    size_t size = qtumPeekSize();
+    if(size > maxSize){
+        size = maxSize;
+    }
+    memcpy(buffer, items[itemCount - 1].data, size);
+    itemCount--;
+    return size;
+}
+
+void qtumPopExact(void* buffer, size_t size){
+    if(qtumPeekSize() != size){
+        qtumError("item size mismatch");
+    }
+    qtumPop(buffer, size);
+}
+
+#define PUSH_POP(bits) \
+    void qtumPush##bits(uint##bits##_t value){ qtumPush(&value, sizeof(value)); } \
+    uint##bits##_t qtumPop##bits(){ uint##bits##_t value; qtumPopExact(&value, sizeof(value)); return value; }
+
+PUSH_POP(8)
+PUSH_POP(16)
+PUSH_POP(32)
+PUSH_POP(64)
+
+size_t qtumLoad(const void* key, size_t keySize, void* value, size_t maxSize){
+    for(size_t i = 0; i < storage.count; i++){
+        if(storage.entries[i].keySize == keySize && memcmp(storage.entries[i].key, key, keySize) == 0){
+            size_t size = storage.entries[i].size < maxSize ? storage.entries[i].size : maxSize;
+            memcpy(value, storage.entries[i].value, size);
+            return size;
+        }
+    }
+    return 0;
+}
+
+void qtumStore(const void* key, size_t keySize, const void* value, size_t size){
+    size_t i = 0;
+    while(i < storage.count && !(storage.entries[i].keySize == keySize && memcmp(storage.entries[i].key, key, keySize) == 0)){
+        i++;
+    }
+    if(i == MAX_ENTRIES || keySize > sizeof(storage.entries[0].key) || size > sizeof(storage.entries[0].value)){
+        fail("storage is full");
+    }
+    memcpy(storage.entries[i].key, key, keySize);
+    storage.entries[i].keySize = keySize;
+    memcpy(storage.entries[i].value, value, size);
+    storage.entries[i].size = size;
+    if(i == storage.count){
+        storage.count++;
+    }
+}
+
+// call runs withdraw(mode) in a new frame, returning whether it succeeded
+static int call(uint8_t mode){
+    if(frameCount == MAX_FRAMES){
+        fail("too many nested calls");
+    }
+    memcpy(&frames[frameCount].snapshot, &storage, sizeof(storage));
+    frames[frameCount].itemCount = itemCount;
+    qtumPush8(mode);
+    qtumPush32(ID_Guard_withdraw);
+    frameCount++;
+    if(setjmp(frames[frameCount - 1].error) != 0){
+        frameCount--;
+        memcpy(&storage, &frames[frameCount].snapshot, sizeof(storage));
+        itemCount = frames[frameCount].itemCount;
+        return 0;
+    }
+    dispatch();
+    frameCount--;
+    return 1;
+}
+
+// withdraw returns for mode 0, calls itself again for mode 1 and fails for mode 2
+void Guard_withdraw_dispatch(uint8_t mode){
+    printf("withdraw mode=%u\n", mode);
+    if(mode == 1){
+        printf("nested call %s\n", call(0) ? "accepted" : "rejected");
+    }
+    if(mode == 2){
+        qtumError("withdraw failed");
+    }
+}
+
+int main(){
+    static const uint8_t modes[] = {0, 1, 2, 0};
+    for(size_t i = 0; i < sizeof(modes); i++){
+        printf("-> %s\n", call(modes[i]) ? "ok" : "failed");
+    }
+    return 0;
+}
@@ -0,0 +1,212 @@
+/*
+ * calls the dispatcher generated from testdata/golden/Token.abi through the generated TokenABI.c in one process for
+ * TestCRoundTrip, printing what the calls returned like cpp/main.cpp. qtumCall dispatches the call data on the
+ * stack to the contract functions, which log the inputs they received and return outputs computed from them
+ * like runtime.c. qtumError fails the call it happens in
+ */
+#include <setjmp.h>
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <qtum.h>
+#include "TokenDispatcher.h"
+
+QtumCallResult Token_transfer__addr_u64(const UniversalAddress *__address, const QtumCallOptions* __options, const UniversalAddressABI* to, uint64_t amount, uint8_t* ok);
+QtumCallResult Token_transfer__addr_u64_u8arr(const UniversalAddress *__address, const QtumCallOptions* __options, const UniversalAddressABI* to, uint64_t amount, const uint8_t* data, size_t data_sz);
+QtumCallResult Token_adjust(const UniversalAddress *__address, const QtumCallOptions* __options, const uint8_t* key, int32_t delta, const UniversalAddressABI* who, size_t who_sz, uint32_t* keys, int64_t* total, UniversalAddressABI** list, size_t* list_sz);
+QtumCallResult Token_ping(const UniversalAddress *__address, const QtumCallOptions* __options);
+
+#define MAX_ITEMS 16
+
+static struct {
+    uint8_t data[1024];
+    size_t size;
+} items[MAX_ITEMS];
+static size_t itemCount = 0;
+
+static QtumExec exec;
+const QtumExec* qtumExec = &exec;
+
+static uint8_t lock = 0;
+static jmp_buf callError;
+
+void qtumError(const char* msg){
+    printf("error: %s\n", msg);
+    longjmp(callError, 1);
+}
+
+void qtumPush(const void* buffer, size_t size){
+    if(itemCount == MAX_ITEMS || size > sizeof(items[0].data)){
+        fprintf(stderr, "mock runtime: stack overflow\n");
+        exit(2);
+    }
+    memcpy(items[itemCount].data, buffer, size);
+    items[itemCount].size = size;
+    itemCount++;
+}
+
+size_t qtumPeekSize(){
+    if(itemCount == 0){
+        qtumError("peek on an empty stack");
+    }
+    return items[itemCount - 1].size;
+}
+
+size_t qtumPop(void* buffer, size_t maxSize){
+    size_t size = qtumPeekSize();
+    if(size > maxSize){
+        size = maxSize;
+    }
+    memcpy(buffer, items[itemCount - 1].data, size);
+    itemCount--;
+    return size;
+}
+
+void qtumPopExact(void* buffer, size_t size){
+    if(qtumPeekSize() != size){
+        qtumError("item size mismatch");
+    }
+    qtumPop(buffer, size);
+}
+
+#define PUSH_POP(bits) \
+    void qtumPush##bits(uint##bits##_t value){ qtumPush(&value, sizeof(value)); } \
+    uint##bits##_t qtumPop##bits(){ uint##bits##_t value; qtumPopExact(&value, sizeof(value)); return value; }
+
+PUSH_POP(8)
+PUSH_POP(16)
+PUSH_POP(32)
+PUSH_POP(64)
+
+size_t qtumLoad(const void* key, size_t keySize, void* value, size_t maxSize){
+    memcpy(value, &lock, sizeof(lock));
+    return sizeof(lock);
+}
+
+void qtumStore(const void* key, size_t keySize, const void* value, size_t size){
+    memcpy(&lock, value, sizeof(lock));
+}
+
+QtumCallResult qtumCall(const UniversalAddress* address, const QtumCallOptions* options){
+    QtumCallResult result;
+    result.error = QTUM_CALL_SUCCESS;
+    exec.valueSent = options->value;
+    if(setjmp(callError) != 0){
+        itemCount = 0;
+        lock = 0;
+        result.error = 1;
+        return result;
+    }
+    dispatch();
+    return result;
+}
+
+static UniversalAddressABI address(uint32_t version, uint8_t first){
+    UniversalAddressABI address;
+    memset(&address, 0, sizeof(address));
+    address.version = version;
+    address.data[0] = first;
+    return address;
+}
+
+static void logAddress(const char* name, const UniversalAddressABI* address){
+    printf(" %s=%u:%02x", name, address->version, address->data[0]);
+}
+
+void Token_transfer__addr_u64_dispatch(const UniversalAddressABI* to, uint64_t amount, const UniversalAddressABI* from, uint8_t* ok){
+    printf("transfer");
+    logAddress("to", to);
+    printf(" amount=%llu", (unsigned long long)amount);
+    logAddress("from", from);
+    printf(" value=%llu\n", (unsigned long long)qtumExec->valueSent);
+    *ok = (uint8_t)(amount + to->version);
+}
+
+void Token_transfer__addr_u64_u8arr_dispatch(const UniversalAddressABI* to, uint64_t amount, const uint8_t* data, size_t data_sz){
+    printf("transfer");
+    logAddress("to", to);
+    printf(" amount=%llu data=", (unsigned long long)amount);
+    for(size_t i = 0; i < data_sz; i++){
+        printf("%02x", data[i]);
+    }
+    printf("\n");
+}
+
+void Token_adjust_dispatch(const uint8_t* key, int32_t delta, const UniversalAddressABI* who, size_t who_sz, uint64_t v, uint32_t* keys, int64_t* total, UniversalAddressABI** list, size_t* list_sz){
+    size_t count = who_sz / sizeof(UniversalAddressABI);
+    printf("adjust key=%u,%u,%u,%u delta=%d who=", key[0], key[1], key[2], key[3], delta);
+    for(size_t i = 0; i < count; i++){
+        logAddress("", &who[i]);
+    }
+    printf("\n");
+    for(size_t i = 0; i < Token_KEY_LEN; i++){
+        keys[i] = key[i] * 10;
+    }
+    *total = delta + (int64_t)v + Token_FLOOR;
+    // the list comes back reversed
+    *list = malloc(who_sz + 1);
+    for(size_t i = 0; i < count; i++){
+        (*list)[i] = who[count - 1 - i];
+    }
+    *list_sz = count;
+}
+
+void Token_ping_dispatch(){
+    printf("ping\n");
+}
+
+void Token_role_admin(UniversalAddressABI* __role){
+    *__role = address(1, 0xaa);
+}
+
+void Token_role_minter(UniversalAddressABI* __role){
+    *__role = address(0, 0);
+}
+
+static const char* err(QtumCallResult r){
+    return r.error == QTUM_CALL_SUCCESS ? "<nil>" : "call failed";
+}
+
+int main(){
+    UniversalAddress contract = address(9, 0x99);
+    QtumCallOptions options = {0, 0};
+    QtumCallOptions value = {0, 7};
+    exec.sender = address(1, 0xaa);
+
+    UniversalAddressABI to = address(2, 0xbb);
+    uint8_t ok = 0;
+    QtumCallResult r = Token_transfer__addr_u64(&contract, &value, &to, 300, &ok);
+    printf("-> ok=%u err=%s\n", ok, err(r));
+
+    to = address(3, 0xcc);
+    uint8_t data[17] = {1, 2, 3};
+    r = Token_transfer__addr_u64_u8arr(&contract, &options, &to, 9, data, 3);
+    printf("-> err=%s\n", err(r));
+
+    r = Token_transfer__addr_u64_u8arr(&contract, &options, &to, 9, data, 17);
+    printf("-> err=%s\n", err(r));
+
+    uint8_t key[Token_KEY_LEN] = {1, 2, 3, 4};
+    UniversalAddressABI who[2] = {address(4, 0x01), address(5, 0x02)};
+    uint32_t keys[Token_KEY_LEN] = {0};
+    int64_t total = 0;
+    UniversalAddressABI* list = NULL;
+    size_t list_sz = 0;
+    r = Token_adjust(&contract, &options, key, -9, who, 2, keys, &total, &list, &list_sz);
+    printf("-> keys=[%u %u %u %u] total=%lld list=", keys[0], keys[1], keys[2], keys[3], (long long)total);
+    for(size_t i = 0; i < list_sz; i++){
+        printf(" %u:%02x", list[i].version, list[i].data[0]);
+    }
+    printf(" err=%s\n", err(r));
+    free(list);
+
+    exec.sender = address(1, 0xbb);
+    list = NULL;
+    r = Token_adjust(&contract, &options, key, -9, who, 0, keys, &total, &list, &list_sz);
+    printf("-> err=%s\n", err(r));
+    exec.sender = address(1, 0xaa);
+
+    r = Token_ping(&contract, &options);
+    printf("-> err=%s\n", err(r));
+    return 0;
+}
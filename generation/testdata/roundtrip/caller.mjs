@@ -0,0 +1,63 @@
+// Calls the dispatcher built by TestTypeScriptRoundTrip through the TypeScript module the test generates,
+// printing what the calls returned in the same format as caller/main.go. The dispatcher logs the inputs it
+// received to the same output. The module is TypeScript for node versions that run it, 22.6 or later, and
+// its JavaScript with the types stripped for older ones.
+//
+// usage: node caller.mjs <path of TokenClient.ts or TokenClient.mjs> <dispatcher>
+
+import { spawnSync } from "node:child_process";
+import { pathToFileURL } from "node:url";
+
+const { Token } = await import(pathToFileURL(process.argv[2]).href);
+
+// the first byte of the 1:xx address the dispatcher is called from, aa holds the admin role
+let sender = "aa";
+
+async function call(data, options) {
+  const result = spawnSync(process.argv[3], [String(options.value ?? 0n), sender], { input: data, stdio: ["pipe", "pipe", 1] });
+  if (result.status !== 0) {
+    throw new Error("exit status " + result.status);
+  }
+  return new Uint8Array(result.stdout);
+}
+
+function address(version, first) {
+  const data = new Uint8Array(32);
+  data[0] = first;
+  return { version, data };
+}
+
+function hex(n) {
+  return n.toString(16).padStart(2, "0");
+}
+
+async function run(f) {
+  try {
+    return [await f(), "<nil>"];
+  } catch (e) {
+    return [undefined, e.message];
+  }
+}
+
+const contract = new Token(call);
+
+const [ok, err1] = await run(() => contract.transfer__addr_u64(address(2, 0xbb), 300n, { value: 7n }));
+console.log("-> ok=" + ok + " err=" + err1);
+
+const [, err2] = await run(() => contract.transfer__addr_u64_u8arr(address(3, 0xcc), 9n, [1, 2, 3]));
+console.log("-> err=" + err2);
+
+const [, err3] = await run(() => contract.transfer__addr_u64_u8arr(address(3, 0xcc), 9n, new Array(17).fill(0)));
+console.log("-> err=" + err3);
+
+const [result, err4] = await run(() => contract.adjust([1, 2, 3, 4], -9, [address(4, 0x01), address(5, 0x02)]));
+const list = result.list.map((a) => " " + a.version + ":" + hex(a.data[0])).join("");
+console.log("-> keys=[" + result.keys.join(" ") + "] total=" + result.total + " list=" + list + " err=" + err4);
+
+sender = "bb";
+const [, err5] = await run(() => contract.adjust([1, 2, 3, 4], -9, []));
+console.log("-> err=" + err5);
+sender = "aa";
+
+const [, err6] = await run(() => contract.ping());
+console.log("-> err=" + err6);
@@ -0,0 +1,67 @@
+"""calls the dispatcher built by TestPythonRoundTrip through the Python module the test generates, printing
+what the calls returned in the same format as caller/main.go. The dispatcher logs the inputs it received
+to the same output
+
+usage: python3 -u caller.py <directory of TokenClient.py> <dispatcher>
+"""
+
+import subprocess
+import sys
+import warnings
+
+sys.path.insert(0, sys.argv[1])
+
+from TokenClient import CallOptions, Token, UniversalAddress  # noqa: E402
+
+
+# the first byte of the 1:xx address the dispatcher is called from, aa holds the admin role
+sender = "aa"
+
+
+def call(data, options):
+    process = subprocess.run([sys.argv[2], str(options.value), sender], input=data, stdout=subprocess.PIPE, stderr=sys.stdout)
+    if process.returncode != 0:
+        raise RuntimeError("exit status %d" % process.returncode)
+    return process.stdout
+
+
+def address(version, first):
+    return UniversalAddress(version, bytes([first]) + bytes(31))
+
+
+def run(f):
+    try:
+        return f(), "<nil>"
+    except RuntimeError as e:
+        return None, str(e)
+
+
+# transfer__addr_u64_u8arr is deprecated
+warnings.simplefilter("ignore", DeprecationWarning)
+
+contract = Token(call)
+
+ok, err = run(lambda: contract.transfer__addr_u64(address(2, 0xBB), 300, CallOptions(value=7)))
+print("-> ok=%s err=%s" % (ok, err))
+
+_, err = run(lambda: contract.transfer__addr_u64_u8arr(address(3, 0xCC), 9, [1, 2, 3]))
+print("-> err=%s" % err)
+
+_, err = run(lambda: contract.transfer__addr_u64_u8arr(address(3, 0xCC), 9, [0] * 17))
+print("-> err=%s" % err)
+
+result, err = run(lambda: contract.adjust([1, 2, 3, 4], -9, [address(4, 0x01), address(5, 0x02)]))
+print("-> keys=[%s] total=%s list=%s err=%s" % (
+    " ".join(str(k) for k in result.keys),
+    result.total,
+    "".join(" %d:%02x" % (a.version, a.data[0]) for a in result.list),
+    err,
+))
+
+sender = "bb"
+_, err = run(lambda: contract.adjust([1, 2, 3, 4], -9, []))
+print("-> err=%s" % err)
+sender = "aa"
+
+_, err = run(lambda: contract.ping())
+print("-> err=%s" % err)
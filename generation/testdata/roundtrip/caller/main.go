@@ -0,0 +1,61 @@
+// Command caller calls the dispatcher built by TestGoRoundTrip through the Go bindings the test generates
+// into the token package next to it, printing what the calls returned. The dispatcher logs the inputs it
+// received to the same output
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+
+	qtumstack "github.com/qtumproject/simple-abi/stack"
+
+	"roundtrip/token"
+)
+
+// sender is the first byte of the 1:xx address the dispatcher is called from, aa holds the admin role
+var sender = "aa"
+
+// dispatcher runs the dispatcher binary once per call
+type dispatcher string
+
+func (d dispatcher) Call(data []byte, options qtumstack.CallOptions) ([]byte, error) {
+	cmd := exec.Command(string(d), strconv.FormatUint(options.Value, 10), sender)
+	cmd.Stdin = bytes.NewReader(data)
+	cmd.Stderr = os.Stdout
+	return cmd.Output()
+}
+
+func address(version uint32, first byte) qtumstack.Address {
+	return qtumstack.Address{Version: version, Data: [32]byte{first}}
+}
+
+func main() {
+	contract := token.NewToken(dispatcher(os.Args[1]))
+
+	ok, err := contract.Transfer__addr_u64(7, address(2, 0xbb), 300)
+	fmt.Printf("-> ok=%v err=%v\n", ok, err)
+
+	err = contract.Transfer__addr_u64_u8arr(address(3, 0xcc), 9, []uint8{1, 2, 3})
+	fmt.Printf("-> err=%v\n", err)
+
+	err = contract.Transfer__addr_u64_u8arr(address(3, 0xcc), 9, make([]uint8, 17))
+	fmt.Printf("-> err=%v\n", err)
+
+	keys, total, list, err := contract.Adjust([4]uint8{1, 2, 3, 4}, -9, []qtumstack.Address{address(4, 0x01), address(5, 0x02)})
+	fmt.Printf("-> keys=%v total=%v list=", keys, total)
+	for _, a := range list {
+		fmt.Printf(" %v:%02x", a.Version, a.Data[0])
+	}
+	fmt.Printf(" err=%v\n", err)
+
+	sender = "bb"
+	_, _, _, err = contract.Adjust([4]uint8{1, 2, 3, 4}, -9, nil)
+	fmt.Printf("-> err=%v\n", err)
+	sender = "aa"
+
+	err = contract.Ping()
+	fmt.Printf("-> err=%v\n", err)
+}
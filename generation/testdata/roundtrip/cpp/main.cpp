@@ -0,0 +1,192 @@
+/*
+ * calls the TokenImpl of testdata/golden/Token.abi through the generated TokenClient in one process for
+ * TestCppRoundTrip, printing what the calls returned like caller/main.go. qtumCall dispatches the call data on
+ * the stack to the implementation, which logs the inputs it received and returns outputs computed from them
+ * like runtime.c. qtumError throws, failing the call it happens in
+ */
+#include <cstdio>
+#include <cstdlib>
+#include <cstring>
+#include <string>
+#include <vector>
+#include "TokenClient.hpp"
+#include "TokenImpl.hpp"
+
+struct CallError {
+    std::string msg;
+};
+
+static std::vector<std::vector<uint8_t>> items;
+
+static QtumExec exec;
+const QtumExec* qtumExec = &exec;
+
+static uint8_t lock = 0;
+
+void qtumError(const char* msg){
+    printf("error: %s\n", msg);
+    throw CallError{msg};
+}
+
+void qtumPush(const void* buffer, size_t size){
+    const uint8_t* bytes = static_cast<const uint8_t*>(buffer);
+    items.push_back(std::vector<uint8_t>(bytes, bytes + size));
+}
+
+size_t qtumPeekSize(){
+    if(items.empty()){
+        qtumError("peek on an empty stack");
+    }
+    return items.back().size();
+}
+
+size_t qtumPop(void* buffer, size_t maxSize){
+    size_t size = qtumPeekSize();
+    if(size > maxSize){
+        size = maxSize;
+    }
+    memcpy(buffer, items.back().data(), size);
+    items.pop_back();
+    return size;
+}
+
+void qtumPopExact(void* buffer, size_t size){
+    if(qtumPeekSize() != size){
+        qtumError("item size mismatch");
+    }
+    qtumPop(buffer, size);
+}
+
+#define PUSH_POP(bits) \
+    void qtumPush##bits(uint##bits##_t value){ qtumPush(&value, sizeof(value)); } \
+    uint##bits##_t qtumPop##bits(){ uint##bits##_t value; qtumPopExact(&value, sizeof(value)); return value; }
+
+PUSH_POP(8)
+PUSH_POP(16)
+PUSH_POP(32)
+PUSH_POP(64)
+
+size_t qtumLoad(const void* key, size_t keySize, void* value, size_t maxSize){
+    memcpy(value, &lock, sizeof(lock));
+    return sizeof(lock);
+}
+
+void qtumStore(const void* key, size_t keySize, const void* value, size_t size){
+    memcpy(&lock, value, sizeof(lock));
+}
+
+static UniversalAddressABI address(uint32_t version, uint8_t first){
+    UniversalAddressABI address;
+    memset(&address, 0, sizeof(address));
+    address.version = version;
+    address.data[0] = first;
+    return address;
+}
+
+static void logAddress(const char* name, const UniversalAddressABI& address){
+    printf(" %s=%u:%02x", name, address.version, address.data[0]);
+}
+
+class Token : public TokenImpl {
+public:
+    uint8_t transfer__addr_u64(const UniversalAddressABI& to, uint64_t amount, const UniversalAddressABI* from) override {
+        printf("transfer");
+        logAddress("to", to);
+        printf(" amount=%llu", (unsigned long long)amount);
+        logAddress("from", *from);
+        printf(" value=%llu\n", (unsigned long long)qtumExec->valueSent);
+        return (uint8_t)(amount + to.version);
+    }
+
+    void transfer__addr_u64_u8arr(const UniversalAddressABI& to, uint64_t amount, const std::vector<uint8_t>& data) override {
+        printf("transfer");
+        logAddress("to", to);
+        printf(" amount=%llu data=", (unsigned long long)amount);
+        for(uint8_t b : data){
+            printf("%02x", b);
+        }
+        printf("\n");
+    }
+
+    adjust_result adjust(const std::array<uint8_t, 4>& key, int32_t delta, const std::vector<UniversalAddressABI>& who, uint64_t v) override {
+        printf("adjust key=%u,%u,%u,%u delta=%d who=", key[0], key[1], key[2], key[3], delta);
+        for(const UniversalAddressABI& a : who){
+            logAddress("", a);
+        }
+        printf("\n");
+        adjust_result result;
+        for(size_t i = 0; i < Token_KEY_LEN; i++){
+            result.keys[i] = key[i] * 10;
+        }
+        result.total = delta + (int64_t)v + Token_FLOOR;
+        // the list comes back reversed
+        result.list.assign(who.rbegin(), who.rend());
+        return result;
+    }
+
+    void ping() override {
+        printf("ping\n");
+    }
+
+    UniversalAddressABI role_admin() override {
+        return address(1, 0xaa);
+    }
+
+    UniversalAddressABI role_minter() override {
+        return address(0, 0);
+    }
+};
+
+static Token contract;
+
+QtumCallResult qtumCall(const UniversalAddress* address, const QtumCallOptions* options){
+    QtumCallResult result;
+    result.error = QTUM_CALL_SUCCESS;
+    exec.valueSent = options->value;
+    try {
+        dispatch(contract);
+    } catch(const CallError&) {
+        items.clear();
+        lock = 0;
+        result.error = 1;
+    }
+    return result;
+}
+
+static const char* err(const TokenClient& client){
+    return client.lastCallResult().error == QTUM_CALL_SUCCESS ? "<nil>" : "call failed";
+}
+
+int main(){
+    TokenClient client(address(9, 0x99));
+    QtumCallOptions options = {0, 0};
+    QtumCallOptions value = {0, 7};
+    exec.sender = address(1, 0xaa);
+
+    auto ok = client.transfer__addr_u64(value, address(2, 0xbb), 300);
+    printf("-> ok=%u err=%s\n", ok ? ok->ok : 0, err(client));
+
+    client.transfer__addr_u64_u8arr(options, address(3, 0xcc), 9, {1, 2, 3});
+    printf("-> err=%s\n", err(client));
+
+    client.transfer__addr_u64_u8arr(options, address(3, 0xcc), 9, std::vector<uint8_t>(17));
+    printf("-> err=%s\n", err(client));
+
+    auto adjusted = client.adjust(options, {1, 2, 3, 4}, -9, {address(4, 0x01), address(5, 0x02)})
+        .value_or(TokenClient::adjust_result());
+    printf("-> keys=[%u %u %u %u] total=%lld list=", adjusted.keys[0], adjusted.keys[1], adjusted.keys[2],
+        adjusted.keys[3], (long long)adjusted.total);
+    for(const UniversalAddressABI& a : adjusted.list){
+        printf(" %u:%02x", a.version, a.data[0]);
+    }
+    printf(" err=%s\n", err(client));
+
+    exec.sender = address(1, 0xbb);
+    client.adjust(options, {1, 2, 3, 4}, -9, {});
+    printf("-> err=%s\n", err(client));
+    exec.sender = address(1, 0xaa);
+
+    client.ping(options);
+    printf("-> err=%s\n", err(client));
+    return 0;
+}
@@ -0,0 +1,54 @@
+/*
+ * mock of the parts of qtum.h the generated code uses, see runtime.c, c/main.c and cpp/main.cpp
+ */
+#ifndef QTUM_H
+#define QTUM_H
+
+#include <stddef.h>
+#include <stdint.h>
+
+typedef struct {
+    uint32_t version;
+    uint8_t data[32];
+} __attribute__((__packed__)) UniversalAddressABI;
+
+typedef UniversalAddressABI UniversalAddress;
+
+typedef struct {
+    uint64_t gasLimit;
+    uint64_t value;
+} QtumCallOptions;
+
+#define QTUM_CALL_SUCCESS 0
+
+typedef struct {
+    uint32_t error;
+} QtumCallResult;
+
+typedef struct {
+    UniversalAddressABI sender;
+    UniversalAddressABI origin;
+    uint64_t valueSent;
+} QtumExec;
+
+extern const QtumExec* qtumExec;
+
+void qtumPush(const void* buffer, size_t size);
+void qtumPush8(uint8_t value);
+void qtumPush16(uint16_t value);
+void qtumPush32(uint32_t value);
+void qtumPush64(uint64_t value);
+size_t qtumPop(void* buffer, size_t maxSize);
+void qtumPopExact(void* buffer, size_t size);
+uint8_t qtumPop8();
+uint16_t qtumPop16();
+uint32_t qtumPop32();
+uint64_t qtumPop64();
+size_t qtumPeekSize();
+void qtumErase();
+void qtumError(const char* msg);
+QtumCallResult qtumCall(const UniversalAddress* address, const QtumCallOptions* options);
+size_t qtumLoad(const void* key, size_t keySize, void* value, size_t maxSize);
+void qtumStore(const void* key, size_t keySize, const void* value, size_t size);
+
+#endif
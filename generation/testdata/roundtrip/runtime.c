@@ -0,0 +1,182 @@
+/*
+ * mock Qtum runtime running the dispatcher generated from Token.abi once: it reads call data from stdin,
+ * takes the value sent from its first argument and the first byte of the 1:xx sender from the optional second
+ * one, defaulting to the admin 1:aa, dispatches and writes the outputs to stdout in the
+ * serialized stack format of the stack package. The contract functions log their inputs to stderr
+ * and return outputs computed from them
+ */
+#include <stdio.h>
+#include <stdlib.h>
+#include <string.h>
+#include <qtum.h>
+#include "TokenDispatcher.h"
+
+#define MAX_ITEMS 64
+
+static struct {
+    uint8_t* data;
+    size_t size;
+} items[MAX_ITEMS];
+static size_t itemCount = 0;
+
+static QtumExec exec;
+const QtumExec* qtumExec = &exec;
+
+static uint8_t lock = 0;
+
+void qtumError(const char* msg){
+    fprintf(stderr, "error: %s\n", msg);
+    exit(1);
+}
+
+void qtumPush(const void* buffer, size_t size){
+    if(itemCount == MAX_ITEMS){
+        qtumError("stack overflow");
+    }
+    items[itemCount].data = malloc(size + 1);
+    memcpy(items[itemCount].data, buffer, size);
+    items[itemCount].size = size;
+    itemCount++;
+}
+
+size_t qtumPeekSize(){
+    if(itemCount == 0){
+        qtumError("peek on an empty stack");
+    }
+    return items[itemCount - 1].size;
+}
+
+size_t qtumPop(void* buffer, size_t maxSize){
+    size_t size = qtumPeekSize();
+    if(size > maxSize){
+        size = maxSize;
+    }
+    memcpy(buffer, items[itemCount - 1].data, size);
+    itemCount--;
+    return size;
+}
+
+void qtumPopExact(void* buffer, size_t size){
+    if(qtumPeekSize() != size){
+        qtumError("item size mismatch");
+    }
+    qtumPop(buffer, size);
+}
+
+#define PUSH_POP(bits) \
+    void qtumPush##bits(uint##bits##_t value){ qtumPush(&value, sizeof(value)); } \
+    uint##bits##_t qtumPop##bits(){ uint##bits##_t value; qtumPopExact(&value, sizeof(value)); return value; }
+
+PUSH_POP(8)
+PUSH_POP(16)
+PUSH_POP(32)
+PUSH_POP(64)
+
+size_t qtumLoad(const void* key, size_t keySize, void* value, size_t maxSize){
+    memcpy(value, &lock, sizeof(lock));
+    return sizeof(lock);
+}
+
+void qtumStore(const void* key, size_t keySize, const void* value, size_t size){
+    memcpy(&lock, value, sizeof(lock));
+}
+
+static void logAddress(const char* name, const UniversalAddressABI* address){
+    fprintf(stderr, " %s=%u:%02x", name, address->version, address->data[0]);
+}
+
+void Token_transfer__addr_u64_dispatch(const UniversalAddressABI* to, uint64_t amount, const UniversalAddressABI* from, uint8_t* ok){
+    fprintf(stderr, "transfer");
+    logAddress("to", to);
+    fprintf(stderr, " amount=%llu", (unsigned long long)amount);
+    logAddress("from", from);
+    fprintf(stderr, " value=%llu\n", (unsigned long long)qtumExec->valueSent);
+    *ok = (uint8_t)(amount + to->version);
+}
+
+void Token_transfer__addr_u64_u8arr_dispatch(const UniversalAddressABI* to, uint64_t amount, const uint8_t* data, size_t data_sz){
+    fprintf(stderr, "transfer");
+    logAddress("to", to);
+    fprintf(stderr, " amount=%llu data=", (unsigned long long)amount);
+    for(size_t i = 0; i < data_sz; i++){
+        fprintf(stderr, "%02x", data[i]);
+    }
+    fprintf(stderr, "\n");
+}
+
+void Token_adjust_dispatch(const uint8_t* key, int32_t delta, const UniversalAddressABI* who, size_t who_sz, uint64_t v, uint32_t* keys, int64_t* total, UniversalAddressABI** list, size_t* list_sz){
+    size_t count = who_sz / sizeof(UniversalAddressABI);
+    fprintf(stderr, "adjust key=%u,%u,%u,%u delta=%d who=", key[0], key[1], key[2], key[3], delta);
+    for(size_t i = 0; i < count; i++){
+        logAddress("", &who[i]);
+    }
+    fprintf(stderr, "\n");
+    for(size_t i = 0; i < Token_KEY_LEN; i++){
+        keys[i] = key[i] * 10;
+    }
+    *total = delta + (int64_t)v + Token_FLOOR;
+    // the list comes back reversed
+    *list = malloc(who_sz + 1);
+    for(size_t i = 0; i < count; i++){
+        (*list)[i] = who[count - 1 - i];
+    }
+    *list_sz = count;
+}
+
+void Token_ping_dispatch(){
+    fprintf(stderr, "ping\n");
+}
+
+void Token_role_admin(UniversalAddressABI* __role){
+    memset(__role, 0, sizeof(UniversalAddressABI));
+    __role->version = 1;
+    __role->data[0] = 0xaa;
+}
+
+void Token_role_minter(UniversalAddressABI* __role){
+    memset(__role, 0, sizeof(UniversalAddressABI));
+}
+
+int main(int argc, char** argv){
+    static uint8_t input[1 << 16];
+    size_t size = fread(input, 1, sizeof(input), stdin);
+    // items are listed bottom to top, each followed by its length
+    size_t count = 0;
+    size_t ends[MAX_ITEMS];
+    for(size_t end = size; end > 0; count++){
+        uint32_t itemSize;
+        if(end < 4 || count == MAX_ITEMS){
+            qtumError("malformed call data");
+        }
+        memcpy(&itemSize, input + end - 4, 4);
+        if(itemSize > end - 4){
+            qtumError("malformed call data");
+        }
+        ends[count] = end - 4;
+        end -= 4 + itemSize;
+    }
+    for(size_t i = count; i > 0; i--){
+        size_t end = ends[i - 1];
+        uint32_t itemSize;
+        memcpy(&itemSize, input + end, 4);
+        qtumPush(input + end - itemSize, itemSize);
+    }
+
+    exec.sender.version = 1;
+    exec.sender.data[0] = 0xaa;
+    if(argc > 1){
+        exec.valueSent = strtoull(argv[1], NULL, 10);
+    }
+    if(argc > 2){
+        exec.sender.data[0] = (uint8_t)strtoul(argv[2], NULL, 16);
+    }
+
+    dispatch();
+
+    for(size_t i = 0; i < itemCount; i++){
+        uint32_t itemSize = (uint32_t)items[i].size;
+        fwrite(items[i].data, 1, items[i].size, stdout);
+        fwrite(&itemSize, 1, sizeof(itemSize), stdout);
+    }
+    return 0;
+}
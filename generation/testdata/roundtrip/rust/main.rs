@@ -0,0 +1,115 @@
+//! calls the Token trait of testdata/golden/Token.abi through the wrappers of TokenABI.rs in one process for
+//! TestRustRoundTrip, printing what the calls returned like cpp/main.cpp. qtum::call dispatches the call data
+//! on the stack to the implementation, which logs the inputs it received and returns outputs computed from them
+
+#![allow(deprecated, non_snake_case)]
+
+mod TokenABI;
+mod TokenDispatcher;
+
+use qtum::{CallOptions, CallResult, UniversalAddressABI};
+use TokenDispatcher::{Token, FLOOR, KEY_LEN};
+
+fn address(version: u32, first: u8) -> UniversalAddressABI {
+    UniversalAddressABI::new(version, first)
+}
+
+fn log_address(name: &str, address: &UniversalAddressABI) {
+    print!(" {}={}:{:02x}", name, address.version(), address.first());
+}
+
+struct Contract;
+
+impl Token for Contract {
+    fn transfer__addr_u64(&mut self, to: UniversalAddressABI, amount: u64, from: &UniversalAddressABI) -> u8 {
+        print!("transfer");
+        log_address("to", &to);
+        print!(" amount={}", amount);
+        log_address("from", from);
+        println!(" value={}", qtum::exec().value_sent);
+        (amount + to.version() as u64) as u8
+    }
+
+    fn transfer__addr_u64_u8arr(&mut self, to: UniversalAddressABI, amount: u64, data: Vec<u8>) {
+        print!("transfer");
+        log_address("to", &to);
+        print!(" amount={} data=", amount);
+        for b in data {
+            print!("{:02x}", b);
+        }
+        println!();
+    }
+
+    fn adjust(&mut self, key: [u8; 4], delta: i32, who: Vec<UniversalAddressABI>, v: u64) -> ([u32; 4], i64, Vec<UniversalAddressABI>) {
+        print!("adjust key={},{},{},{} delta={} who=", key[0], key[1], key[2], key[3], delta);
+        for a in &who {
+            log_address("", a);
+        }
+        println!();
+        let mut keys = [0u32; KEY_LEN as usize];
+        for i in 0..keys.len() {
+            keys[i] = key[i] as u32 * 10;
+        }
+        // the list comes back reversed
+        let list = who.into_iter().rev().collect();
+        (keys, delta as i64 + v as i64 + FLOOR, list)
+    }
+
+    fn ping(&mut self) {
+        println!("ping");
+    }
+
+    fn role_admin(&self) -> UniversalAddressABI {
+        address(1, 0xaa)
+    }
+
+    fn role_minter(&self) -> UniversalAddressABI {
+        address(0, 0)
+    }
+}
+
+fn dispatch() {
+    TokenDispatcher::dispatch(&mut Contract);
+}
+
+fn err<T>(result: &Result<T, CallResult>) -> &'static str {
+    if result.is_ok() {
+        "<nil>"
+    } else {
+        "call failed"
+    }
+}
+
+fn main() {
+    qtum::quiet();
+    qtum::set_contract(dispatch);
+    let contract = address(9, 0x99);
+    let options = CallOptions { gas_limit: 0, value: 0 };
+    let value = CallOptions { gas_limit: 0, value: 7 };
+    qtum::set_sender(address(1, 0xaa));
+
+    let ok = TokenABI::transfer__addr_u64(&contract, &value, &address(2, 0xbb), 300);
+    println!("-> ok={} err={}", ok.as_ref().map_or(0, |ok| *ok), err(&ok));
+
+    let result = TokenABI::transfer__addr_u64_u8arr(&contract, &options, &address(3, 0xcc), 9, &[1, 2, 3]);
+    println!("-> err={}", err(&result));
+
+    let result = TokenABI::transfer__addr_u64_u8arr(&contract, &options, &address(3, 0xcc), 9, &[0; 17]);
+    println!("-> err={}", err(&result));
+
+    let result = TokenABI::adjust(&contract, &options, &[1, 2, 3, 4], -9, &[address(4, 0x01), address(5, 0x02)]);
+    let (keys, total, list) = result.as_ref().cloned().unwrap_or_default();
+    print!("-> keys=[{} {} {} {}] total={} list=", keys[0], keys[1], keys[2], keys[3], total);
+    for a in &list {
+        print!(" {}:{:02x}", a.version(), a.first());
+    }
+    println!(" err={}", err(&result));
+
+    qtum::set_sender(address(1, 0xbb));
+    let result = TokenABI::adjust(&contract, &options, &[1, 2, 3, 4], -9, &[]);
+    println!("-> err={}", err(&result));
+    qtum::set_sender(address(1, 0xaa));
+
+    let result = TokenABI::ping(&contract, &options);
+    println!("-> err={}", err(&result));
+}
@@ -0,0 +1,161 @@
+//! mock of the qtum crate the generated Rust uses, see main.rs. It keeps the call stack in memory and
+//! call dispatches to the contract set with set_contract in the same process. error panics, failing
+//! the call it happens in
+
+use std::cell::RefCell;
+use std::panic;
+
+/// UniversalAddressABI is the version as little endian bytes followed by 32 bytes of address data
+#[derive(Clone, Copy, PartialEq, Debug)]
+pub struct UniversalAddressABI {
+    bytes: [u8; 36],
+}
+
+impl UniversalAddressABI {
+    pub const SIZE: usize = 36;
+
+    pub fn new(version: u32, first: u8) -> Self {
+        let mut bytes = [0u8; 36];
+        bytes[..4].copy_from_slice(&version.to_le_bytes());
+        bytes[4] = first;
+        UniversalAddressABI { bytes }
+    }
+
+    pub fn version(&self) -> u32 {
+        let mut version = [0u8; 4];
+        version.copy_from_slice(&self.bytes[..4]);
+        u32::from_le_bytes(version)
+    }
+
+    pub fn first(&self) -> u8 {
+        self.bytes[4]
+    }
+
+    pub fn as_bytes(&self) -> &[u8] {
+        &self.bytes
+    }
+
+    pub fn from_bytes(bytes: &[u8]) -> Self {
+        let mut address = UniversalAddressABI { bytes: [0u8; 36] };
+        address.bytes.copy_from_slice(bytes);
+        address
+    }
+}
+
+impl Default for UniversalAddressABI {
+    fn default() -> Self {
+        UniversalAddressABI { bytes: [0u8; 36] }
+    }
+}
+
+pub type UniversalAddress = UniversalAddressABI;
+
+pub struct CallOptions {
+    pub gas_limit: u64,
+    pub value: u64,
+}
+
+#[derive(Debug)]
+pub struct CallResult {
+    pub error: u32,
+}
+
+pub const CALL_SUCCESS: u32 = 0;
+
+#[derive(Clone, Default)]
+pub struct Exec {
+    pub sender: UniversalAddressABI,
+    pub origin: UniversalAddressABI,
+    pub value_sent: u64,
+}
+
+struct CallError;
+
+thread_local! {
+    static ITEMS: RefCell<Vec<Vec<u8>>> = RefCell::new(Vec::new());
+    static EXEC: RefCell<Exec> = RefCell::new(Exec::default());
+    static LOCK: RefCell<Vec<u8>> = RefCell::new(vec![0]);
+    static CONTRACT: RefCell<Option<fn()>> = RefCell::new(None);
+}
+
+pub fn exec() -> Exec {
+    EXEC.with(|exec| exec.borrow().clone())
+}
+
+pub fn set_sender(sender: UniversalAddressABI) {
+    EXEC.with(|exec| exec.borrow_mut().sender = sender);
+}
+
+pub fn set_contract(dispatch: fn()) {
+    CONTRACT.with(|contract| *contract.borrow_mut() = Some(dispatch));
+}
+
+pub fn error(msg: &str) -> ! {
+    println!("error: {}", msg);
+    panic::panic_any(CallError)
+}
+
+pub fn push_bytes(bytes: &[u8]) {
+    ITEMS.with(|items| items.borrow_mut().push(bytes.to_vec()));
+}
+
+pub fn pop_bytes() -> Vec<u8> {
+    match ITEMS.with(|items| items.borrow_mut().pop()) {
+        Some(bytes) => bytes,
+        None => error("pop on an empty stack"),
+    }
+}
+
+macro_rules! push_pop {
+    ($($push:ident $pop:ident $t:ty),*) => {$(
+        pub fn $push(value: $t) {
+            push_bytes(&value.to_le_bytes());
+        }
+
+        pub fn $pop() -> $t {
+            let bytes = pop_bytes();
+            if bytes.len() != core::mem::size_of::<$t>() {
+                error("item size mismatch");
+            }
+            let mut word = [0u8; core::mem::size_of::<$t>()];
+            word.copy_from_slice(&bytes);
+            <$t>::from_le_bytes(word)
+        }
+    )*};
+}
+push_pop!(push8 pop8 u8, push16 pop16 u16, push32 pop32 u32, push64 pop64 u64);
+
+// the only key the generated code loads and stores is its reentrancy lock
+pub fn load(_key: &[u8], value: &mut [u8]) {
+    LOCK.with(|lock| value.copy_from_slice(&lock.borrow()[..value.len()]));
+}
+
+pub fn store(_key: &[u8], value: &[u8]) {
+    LOCK.with(|lock| *lock.borrow_mut() = value.to_vec());
+}
+
+pub fn call(_address: &UniversalAddress, options: &CallOptions) -> CallResult {
+    EXEC.with(|exec| exec.borrow_mut().value_sent = options.value);
+    let dispatch = CONTRACT.with(|contract| contract.borrow().expect("no contract to call"));
+    match panic::catch_unwind(dispatch) {
+        Ok(()) => CallResult { error: CALL_SUCCESS },
+        Err(payload) => {
+            if !payload.is::<CallError>() {
+                panic::resume_unwind(payload);
+            }
+            ITEMS.with(|items| items.borrow_mut().clear());
+            store(&[], &[0]);
+            CallResult { error: 1 }
+        }
+    }
+}
+
+/// quiet keeps the default panic hook from reporting the panics of error
+pub fn quiet() {
+    let hook = panic::take_hook();
+    panic::set_hook(Box::new(move |info| {
+        if !info.payload().is::<CallError>() {
+            hook(info);
+        }
+    }));
+}
@@ -0,0 +1,259 @@
+package generation
+
+// tsStackRuntime encodes and decodes the call stack without any dependency, in the wire format of the stack package:
+// items from the bottom to the top, every item followed by its length as a little endian uint32
+const tsStackRuntime = `/** UniversalAddressABI: the address version followed by 32 bytes of address data */
+export interface UniversalAddress {
+  version: number;
+  data: Uint8Array;
+}
+
+/**
+ * options of a single call, value is the amount of coins sent along with it and a gasLimit of 0n
+ * leaves the gas limit to the transport
+ */
+export interface CallOptions {
+  value?: bigint;
+  gasLimit?: bigint;
+}
+
+/**
+ * sends call data to the deployed contract, e.g. through qtumjs or an RPC client of your own, and
+ * resolves to the data the contract returned
+ */
+export type Transport = (data: Uint8Array, options: CallOptions) => Promise<Uint8Array>;
+
+/** integer types that fit in a number */
+export type NumberType = "uint8" | "uint16" | "uint32" | "int8" | "int16" | "int32";
+
+/** integer types that need a bigint */
+export type BigIntType = "uint64" | "int64";
+
+const ADDRESS_SIZE = 36;
+
+const SIZES: Record<NumberType | BigIntType, number> = {
+  uint8: 1, uint16: 2, uint32: 4, uint64: 8,
+  int8: 1, int16: 2, int32: 4, int64: 8,
+};
+
+function writeNumber(view: DataView, offset: number, type: NumberType, value: number): void {
+  const bits = SIZES[type] * 8;
+  const signed = type.startsWith("int");
+  const min = signed ? -(2 ** (bits - 1)) : 0;
+  const max = signed ? 2 ** (bits - 1) - 1 : 2 ** bits - 1;
+  if (!Number.isInteger(value) || value < min || value > max) {
+    throw new RangeError(value + " is not a " + type);
+  }
+  switch (type) {
+    case "uint8": view.setUint8(offset, value); break;
+    case "uint16": view.setUint16(offset, value, true); break;
+    case "uint32": view.setUint32(offset, value, true); break;
+    case "int8": view.setInt8(offset, value); break;
+    case "int16": view.setInt16(offset, value, true); break;
+    case "int32": view.setInt32(offset, value, true); break;
+  }
+}
+
+function readNumber(view: DataView, offset: number, type: NumberType): number {
+  switch (type) {
+    case "uint8": return view.getUint8(offset);
+    case "uint16": return view.getUint16(offset, true);
+    case "uint32": return view.getUint32(offset, true);
+    case "int8": return view.getInt8(offset);
+    case "int16": return view.getInt16(offset, true);
+    case "int32": return view.getInt32(offset, true);
+  }
+}
+
+function writeBigInt(view: DataView, offset: number, type: BigIntType, value: bigint): void {
+  if (type === "uint64") {
+    if (BigInt.asUintN(64, value) !== value) {
+      throw new RangeError(value + " is not a uint64");
+    }
+    view.setBigUint64(offset, value, true);
+  } else {
+    if (BigInt.asIntN(64, value) !== value) {
+      throw new RangeError(value + " is not an int64");
+    }
+    view.setBigInt64(offset, value, true);
+  }
+}
+
+function readBigInt(view: DataView, offset: number, type: BigIntType): bigint {
+  return type === "uint64" ? view.getBigUint64(offset, true) : view.getBigInt64(offset, true);
+}
+
+function writeAddress(view: DataView, offset: number, address: UniversalAddress): void {
+  if (address.data.length !== 32) {
+    throw new RangeError("address data has to be 32 bytes, got " + address.data.length);
+  }
+  view.setUint32(offset, address.version, true);
+  new Uint8Array(view.buffer, view.byteOffset + offset + 4, 32).set(address.data);
+}
+
+function readAddress(view: DataView, offset: number): UniversalAddress {
+  return {
+    version: view.getUint32(offset, true),
+    data: new Uint8Array(view.buffer.slice(view.byteOffset + offset + 4, view.byteOffset + offset + ADDRESS_SIZE)),
+  };
+}
+
+function checkLength(count: number, length?: number): void {
+  if (length !== undefined && count !== length) {
+    throw new RangeError("expected " + length + " elements, got " + count);
+  }
+}
+
+function viewOf(item: Uint8Array): DataView {
+  return new DataView(item.buffer, item.byteOffset, item.byteLength);
+}
+
+/**
+ * call stack Qtum x86 contracts receive their inputs on and push their outputs onto.
+ * Integers are little endian, arrays are their elements back to back
+ */
+export class Stack {
+  items: Uint8Array[] = [];
+
+  /** parses call data or the data returned by a call */
+  static decode(data: Uint8Array): Stack {
+    const stack = new Stack();
+    const view = viewOf(data);
+    let end = data.length;
+    while (end > 0) {
+      if (end < 4) {
+        throw new Error("stack: " + end + " bytes left before the first item, expected a length");
+      }
+      const size = view.getUint32(end - 4, true);
+      if (size > end - 4) {
+        throw new Error("stack: item of " + size + " bytes does not fit in the " + (end - 4) + " bytes before it");
+      }
+      stack.items.unshift(data.slice(end - 4 - size, end - 4));
+      end -= 4 + size;
+    }
+    return stack;
+  }
+
+  encode(): Uint8Array {
+    const out = new Uint8Array(this.items.reduce((size, item) => size + item.length + 4, 0));
+    const view = viewOf(out);
+    let offset = 0;
+    for (const item of this.items) {
+      out.set(item, offset);
+      view.setUint32(offset + item.length, item.length, true);
+      offset += item.length + 4;
+    }
+    return out;
+  }
+
+  push(item: Uint8Array): void {
+    this.items.push(item);
+  }
+
+  pop(): Uint8Array {
+    const item = this.items.pop();
+    if (item === undefined) {
+      throw new Error("stack: pop from an empty stack");
+    }
+    return item;
+  }
+
+  pushNumber(type: NumberType, value: number): void {
+    this.pushNumbers(type, [value]);
+  }
+
+  popNumber(type: NumberType): number {
+    return this.popNumbers(type, 1)[0];
+  }
+
+  pushBigInt(type: BigIntType, value: bigint): void {
+    this.pushBigInts(type, [value]);
+  }
+
+  popBigInt(type: BigIntType): bigint {
+    return this.popBigInts(type, 1)[0];
+  }
+
+  pushAddress(address: UniversalAddress): void {
+    this.pushAddresses([address]);
+  }
+
+  popAddress(): UniversalAddress {
+    return this.popAddresses(1)[0];
+  }
+
+  pushNumbers(type: NumberType, values: number[], length?: number): void {
+    checkLength(values.length, length);
+    const item = new Uint8Array(values.length * SIZES[type]);
+    values.forEach((value, i) => writeNumber(viewOf(item), i * SIZES[type], type, value));
+    this.push(item);
+  }
+
+  popNumbers(type: NumberType, length?: number): number[] {
+    const item = this.popArray(SIZES[type], length);
+    return Array.from({ length: item.length / SIZES[type] }, (_, i) => readNumber(viewOf(item), i * SIZES[type], type));
+  }
+
+  pushBigInts(type: BigIntType, values: bigint[], length?: number): void {
+    checkLength(values.length, length);
+    const item = new Uint8Array(values.length * 8);
+    values.forEach((value, i) => writeBigInt(viewOf(item), i * 8, type, value));
+    this.push(item);
+  }
+
+  popBigInts(type: BigIntType, length?: number): bigint[] {
+    const item = this.popArray(8, length);
+    return Array.from({ length: item.length / 8 }, (_, i) => readBigInt(viewOf(item), i * 8, type));
+  }
+
+  pushAddresses(values: UniversalAddress[], length?: number): void {
+    checkLength(values.length, length);
+    const item = new Uint8Array(values.length * ADDRESS_SIZE);
+    values.forEach((value, i) => writeAddress(viewOf(item), i * ADDRESS_SIZE, value));
+    this.push(item);
+  }
+
+  popAddresses(length?: number): UniversalAddress[] {
+    const item = this.popArray(ADDRESS_SIZE, length);
+    return Array.from({ length: item.length / ADDRESS_SIZE }, (_, i) => readAddress(viewOf(item), i * ADDRESS_SIZE));
+  }
+
+  private popArray(size: number, length?: number): Uint8Array {
+    const item = this.pop();
+    if (length !== undefined && item.length !== size * length) {
+      throw new Error("stack: item of " + item.length + " bytes, expected " + size * length);
+    }
+    if (item.length % size !== 0) {
+      throw new Error("stack: item of " + item.length + " bytes is not an array of " + size + " byte elements");
+    }
+    return item;
+  }
+}
+`
+
+// tsClientTemplateImpl is a template used for generation of the TypeScript module calling a deployed contract
+const tsClientTemplateImpl = `{{ $contractName := .ContractName }}{{.GenFileHeaderTS}}// Calls the functions of a deployed {{$contractName}} contract, see the {{$contractName}} class.
+// Encoding and decoding need no dependency, calls go through the Transport passed to the class.
+
+` + tsStackRuntime + `
+{{if .Constants}}// Constants
+{{range .Constants}}{{.GenConstTS}}
+{{end}}
+{{end}}// Function IDs, pushed on top of the inputs of a call
+{{range .Functions}}export const ID_{{$contractName}}_{{.CName}} = {{.GenHashedFuncIdentifier $contractName}};
+{{end}}
+{{range .Functions}}{{.GenResultInterfaceTS}}{{.GenEncodeTS $contractName}}
+
+{{.GenDecodeTS}}
+
+{{end}}/** calls the functions of a deployed {{$contractName}} contract through a Transport */
+export class {{$contractName}} {
+  private readonly __transport: Transport;
+
+  constructor(transport: Transport) {
+    this.__transport = transport;
+  }
+{{range .Functions}}
+  {{.GenMethodTS}}
+{{end}}}
+`
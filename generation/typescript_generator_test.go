@@ -0,0 +1,263 @@
+package generation
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/qtumproject/simple-abi/parser"
+)
+
+// TestTypeScriptRoundTrip calls the C dispatcher of testdata/golden/Token.abi through the generated TypeScript module,
+// see testdata/roundtrip/caller.mjs. Node runs the module as it is from 22.6 on, older versions get it with
+// its types stripped by stripTypes
+func TestTypeScriptRoundTrip(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is needed to run the TypeScript round trip")
+	}
+	dispatcher := buildDispatcher(t)
+	dir := t.TempDir()
+	module := filepath.Join(dir, "TokenClient.ts")
+	generate(t, parseToken(t), module, EncodeTypeScript)
+
+	flags := []string{"--experimental-strip-types", "--no-warnings"}
+	if err := exec.Command("node", append(flags, "-e", "")...).Run(); err != nil {
+		flags = nil
+		module = writeStripped(t, module, filepath.Join(dir, "TokenClient.mjs"))
+	}
+	caller := filepath.Join("testdata", "roundtrip", "caller.mjs")
+	out, err := exec.Command("node", append(flags, caller, module, dispatcher)...).CombinedOutput()
+	if err != nil {
+		t.Fatalf("Unexpected error running the caller: %v\n%s", err, out)
+	}
+	checkRoundTrip(t, out)
+}
+
+// TestStripTypes checks that the golden TypeScript modules are still valid JavaScript once stripTypes is done
+// with them, so the round trip keeps working on node versions that don't run TypeScript
+func TestStripTypes(t *testing.T) {
+	if _, err := exec.LookPath("node"); err != nil {
+		t.Skip("node is needed to check the stripped TypeScript")
+	}
+	modules, err := filepath.Glob(filepath.Join("testdata", "golden", "*", "ts", "*.ts.golden"))
+	if err != nil || len(modules) == 0 {
+		t.Fatalf("Expected golden TypeScript modules, got %v: %v", modules, err)
+	}
+	dir := t.TempDir()
+	for _, module := range modules {
+		stripped := writeStripped(t, module, filepath.Join(dir, strings.TrimSuffix(filepath.Base(module), ".ts.golden")+".mjs"))
+		if out, err := exec.Command("node", "--check", stripped).CombinedOutput(); err != nil {
+			t.Errorf("Unexpected error checking %v with its types stripped: %v\n%s", module, err, out)
+		}
+	}
+	ts := "export interface A {\n  b?: bigint;\n}\nexport type N = \"uint8\" | \"int8\";\n" +
+		"const S: Record<N, number> = { uint8: 1, int8: 1 };\n" +
+		"class C {\n  private readonly t: A;\n  items: number[] = [];\n" +
+		"  f(type: N, n?: number, o: A = {}): Promise<void> {\n" +
+		"    switch (type) {\n      case \"uint8\": return n === undefined ? S[type] : n;\n    }\n  }\n}\n"
+	want := "\n\nconst S = { uint8: 1, int8: 1 };\n" +
+		"class C {\n  t;\n  items = [];\n" +
+		"  f(type, n, o = {}) {\n" +
+		"    switch (type) {\n      case \"uint8\": return n === undefined ? S[type] : n;\n    }\n  }\n}\n"
+	if got := stripTypes(ts); got != want {
+		t.Errorf("Unexpected stripped TypeScript, got:\n%s\nwant:\n%s", got, want)
+	}
+}
+
+// writeStripped writes the TypeScript module at path to out as JavaScript, returning out
+func writeStripped(t *testing.T, path string, out string) string {
+	ts, err := ioutil.ReadFile(path)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if err := ioutil.WriteFile(out, []byte(stripTypes(string(ts))), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	return out
+}
+
+// tsToken is a token of stripTypes, space holds whitespace and comments
+type tsToken struct {
+	text  string
+	space bool
+}
+
+// tokenizeTS splits TypeScript into identifiers, numbers, strings, punctuation and the space between them
+func tokenizeTS(ts string) []tsToken {
+	var tokens []tsToken
+	isWord := func(c byte) bool {
+		return c == '_' || c == '$' || c >= '0' && c <= '9' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z'
+	}
+	for i := 0; i < len(ts); {
+		start, space := i, false
+		switch c := ts[i]; {
+		case c == ' ' || c == '\t' || c == '\n' || c == '\r':
+			for space = true; i < len(ts) && strings.IndexByte(" \t\n\r", ts[i]) >= 0; i++ {
+			}
+		case strings.HasPrefix(ts[i:], "//"):
+			for space = true; i < len(ts) && ts[i] != '\n'; i++ {
+			}
+		case strings.HasPrefix(ts[i:], "/*"):
+			space = true
+			if end := strings.Index(ts[i+2:], "*/"); end >= 0 {
+				i += end + 4
+			} else {
+				i = len(ts)
+			}
+		case c == '"' || c == '\'' || c == '`':
+			for i++; i < len(ts) && ts[i] != c; i++ {
+				if ts[i] == '\\' {
+					i++
+				}
+			}
+			i++
+		case isWord(c):
+			for ; i < len(ts) && isWord(ts[i]); i++ {
+			}
+		case strings.HasPrefix(ts[i:], "=>") || strings.HasPrefix(ts[i:], "??") || strings.HasPrefix(ts[i:], "?."):
+			i += 2
+		default:
+			i++
+		}
+		if i > len(ts) {
+			i = len(ts)
+		}
+		tokens = append(tokens, tsToken{text: ts[start:i], space: space})
+	}
+	return tokens
+}
+
+// stripTypes turns the TypeScript the generator emits into JavaScript by dropping interfaces, type aliases,
+// type annotations, optional markers and class member modifiers, leaving everything else as it is. It only
+// knows the syntax the generated modules use, TestStripTypes checks it still handles all of it
+func stripTypes(ts string) string {
+	tokens := tokenizeTS(ts)
+	// next returns the index of the first token after i that is not space
+	next := func(i int) int {
+		for i++; i < len(tokens) && tokens[i].space; i++ {
+		}
+		return i
+	}
+	text := func(i int) string {
+		if i < len(tokens) {
+			return tokens[i].text
+		}
+		return ""
+	}
+	// skip returns the index of the first token after i that is one of stops outside of brackets
+	skip := func(i int, stops string) int {
+		depth := 0
+		for i = next(i); i < len(tokens); i = next(i) {
+			s := text(i)
+			if depth == 0 && len(s) == 1 && strings.Contains(stops, s) {
+				break
+			}
+			switch s {
+			case "(", "[", "{", "<":
+				depth++
+			case ")", "]", "}", ">":
+				depth--
+			}
+		}
+		return i
+	}
+
+	type scope struct {
+		object    bool // an object literal, whose colons separate keys from values
+		ternaries int  // question marks still waiting for their colon
+	}
+	scopes := []scope{{}}
+	var out strings.Builder
+	prev, inCase := "", false
+	for i := 0; i < len(tokens); i++ {
+		s := text(i)
+		if tokens[i].space {
+			out.WriteString(s)
+			continue
+		}
+		top := &scopes[len(scopes)-1]
+		declaration := i
+		if s == "export" {
+			declaration = next(i)
+		}
+		atStatement := prev == "" || prev == ";" || prev == "{" || prev == "}"
+		switch {
+		case atStatement && text(declaration) == "interface":
+			i = skip(skip(declaration, "{"), "}")
+			continue
+		case atStatement && text(declaration) == "type" && text(next(next(declaration))) == "=":
+			i = skip(declaration, ";")
+			continue
+		case (s == "private" || s == "readonly" || s == "public") && isTSWord(text(next(i))):
+			i = next(i) - 1
+			continue
+		case s == "?" && text(next(i)) == ":":
+			continue
+		case s == "?":
+			top.ternaries++
+		case s == ":" && top.ternaries > 0:
+			top.ternaries--
+		case s == ":" && (inCase || top.object):
+			inCase = false
+		case s == ":":
+			// a type annotation, dropped along with its type, leaving the space before what follows it
+			end := skip(i, ",)=;{")
+			for i = end - 1; tokens[i].space; i-- {
+			}
+			continue
+		case s == "case" || s == "default":
+			inCase = true
+		case s == "(" || s == "[":
+			scopes = append(scopes, scope{})
+		case s == "{":
+			object := false
+			switch prev {
+			case "=", "(", ",", ":", "[", "?", "??", "return":
+				object = true
+			}
+			scopes = append(scopes, scope{object: object})
+		case s == ")" || s == "]" || s == "}":
+			if len(scopes) > 1 {
+				scopes = scopes[:len(scopes)-1]
+			}
+		}
+		out.WriteString(s)
+		prev = s
+	}
+	return out.String()
+}
+
+func isTSWord(s string) bool {
+	return s != "" && (s[0] == '_' || s[0] == '$' || s[0] >= 'a' && s[0] <= 'z' || s[0] >= 'A' && s[0] <= 'Z')
+}
+
+func TestTypeScriptReservedWords(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "Keywords.abi")
+	abi := ":name=Keywords\nfunction:uint64 instanceof:uint8[2] debugger:fn -> null:int8 with:uniaddress\n"
+	if err := ioutil.WriteFile(path, []byte(abi), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	builders, err := parser.Parse(path, false)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	var b bytes.Buffer
+	if err := GenerateTemplate(builders[0], "KeywordsClient.ts", &b, EncodeTypeScript); err != nil {
+		t.Fatalf("Unexpected error in template generation: %v", err)
+	}
+	for _, want := range []string{
+		"  null: number;\n  with: UniversalAddress;\n",
+		"export function encodeDebugger(function_: bigint, instanceof_: number[]): Uint8Array {",
+		`  __s.pushNumbers("uint8", instanceof_, 2);`,
+		`  __s.pushBigInt("uint64", function_);`,
+		"  const with_ = __s.popAddress();\n  const null_ = __s.popNumber(\"int8\");\n  return { null: null_, with: with_ };",
+		"  async debugger_(function_: bigint, instanceof_: number[], __options: CallOptions = {}): Promise<DebuggerResult> {",
+	} {
+		if !strings.Contains(b.String(), want) {
+			t.Errorf("Expected the generated module to contain %q, got:\n%v", want, b.String())
+		}
+	}
+}
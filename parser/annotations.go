@@ -0,0 +1,208 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/qtumproject/simple-abi/definitions"
+)
+
+// annotationRule describes an annotation the parser accepts, such as @gas(50000)
+type annotationRule struct {
+	// onFunction and onParameter tell what the annotation can be written after
+	onFunction  bool
+	onParameter bool
+	// inputsOnly rules out outputs for annotations on parameters
+	inputsOnly bool
+	// check validates the value of the annotation for the parameter it is written after, which is nil for functions
+	check func(value string, param *definitions.QType) error
+}
+
+// annotationRules is the registry of known annotations, generators can rely on every
+// annotation in QFunc.Annotations and QType.Annotations being listed here
+var annotationRules = map[string]annotationRule{
+	// gas is the gas limit clients use by default when calling the function
+	"gas": {onFunction: true, check: checkPositiveInteger},
+	// maxlen is the largest number of elements the dispatcher accepts for a dynamic array input
+	"maxlen": {onParameter: true, inputsOnly: true, check: func(value string, param *definitions.QType) error {
+		if !strings.HasSuffix(param.Type, "[]") {
+			return fmt.Errorf("can only be used on dynamic arrays")
+		}
+		return checkPositiveInteger(value, param)
+	}},
+	// deprecated marks a function or parameter that should no longer be used, its value says what to use instead
+	"deprecated": {onFunction: true, onParameter: true, check: func(string, *definitions.QType) error { return nil }},
+}
+
+func checkPositiveInteger(value string, param *definitions.QType) error {
+	if number, err := strconv.ParseUint(value, 10, 64); err != nil || number == 0 {
+		return fmt.Errorf("needs a positive integer, got %q", value)
+	}
+	return nil
+}
+
+// signatureAnnotations holds the annotations found in a function signature
+type signatureAnnotations struct {
+	function map[string]string
+	// params is keyed by parameter name
+	params map[string]map[string]string
+}
+
+// tokenize splits a line on spaces, except for spaces inside double quotes
+func tokenize(input string) []string {
+	var tokens []string
+	var token strings.Builder
+	var quoted bool
+	for _, char := range input {
+		if char == '"' {
+			quoted = !quoted
+		}
+		if char == ' ' && !quoted {
+			if token.Len() > 0 {
+				tokens = append(tokens, token.String())
+				token.Reset()
+			}
+			continue
+		}
+		token.WriteRune(char)
+	}
+	if token.Len() > 0 {
+		tokens = append(tokens, token.String())
+	}
+	return tokens
+}
+
+// isAnnotation tells annotations such as @gas(50000) apart from context types such as @sender by their parentheses
+func isAnnotation(token string) bool {
+	return strings.HasPrefix(token, "@") && strings.Contains(token, "(")
+}
+
+// parseAnnotation splits an annotation such as @deprecated("use transfer2") into its key and value
+func parseAnnotation(token string) (string, string, error) {
+	open := strings.Index(token, "(")
+	key := token[1:open]
+	if !strings.HasSuffix(token, ")") || !isValidIdentifier(key) {
+		return "", "", fmt.Errorf("parser error: Invalid annotation %v: needs to be formatted as @key(value)", token)
+	}
+	value := strings.TrimSpace(token[open+1 : len(token)-1])
+	if strings.HasPrefix(value, "\"") {
+		unquoted, err := strconv.Unquote(value)
+		if err != nil {
+			return "", "", fmt.Errorf("parser error: Invalid annotation %v: the value has to be a single quoted string", token)
+		}
+		value = unquoted
+	} else if strings.Contains(value, "\"") {
+		return "", "", fmt.Errorf("parser error: Invalid annotation %v: the value has to be a single quoted string", token)
+	}
+	if breaker := commentBreaker(value); breaker != "" {
+		return "", "", fmt.Errorf("parser error: Invalid annotation %v: the value can't contain %v, which would end the comments of the generated code", token, breaker)
+	}
+	return key, value, nil
+}
+
+// extractAnnotations removes the annotations from a function signature. Every annotation
+// belongs to the name:fn or name:type token right in front of it
+func extractAnnotations(input string) (string, signatureAnnotations, error) {
+	var annotations signatureAnnotations
+	var tokens []string
+	var target map[string]string
+	for _, token := range tokenize(input) {
+		if !isAnnotation(token) {
+			tokens = append(tokens, token)
+			target = nil
+			components := strings.Split(token, ":")
+			if len(components) > 1 && components[1] == "fn" {
+				if annotations.function == nil {
+					annotations.function = make(map[string]string)
+				}
+				target = annotations.function
+			} else if len(components) == 2 {
+				if annotations.params == nil {
+					annotations.params = make(map[string]map[string]string)
+				}
+				if annotations.params[components[0]] == nil {
+					annotations.params[components[0]] = make(map[string]string)
+				}
+				target = annotations.params[components[0]]
+			}
+			continue
+		}
+		if target == nil {
+			return "", signatureAnnotations{}, fmt.Errorf("parser error: annotation %v has to follow a function name or a parameter", token)
+		}
+		key, value, err := parseAnnotation(token)
+		if err != nil {
+			return "", signatureAnnotations{}, err
+		}
+		if _, exists := target[key]; exists {
+			return "", signatureAnnotations{}, fmt.Errorf("parser error: annotation @%v used more than once on the same function or parameter", key)
+		}
+		target[key] = value
+	}
+	return strings.Join(tokens, " "), annotations, nil
+}
+
+// applyAnnotations checks the annotations found by extractAnnotations against annotationRules
+// and stores them on the function and its parameters
+func applyAnnotations(daFunq *definitions.QFunc, annotations signatureAnnotations) error {
+	for _, key := range sortedKeys(annotations.function) {
+		rule, known := annotationRules[key]
+		if !known {
+			return unknownAnnotationError(key)
+		}
+		if !rule.onFunction {
+			return fmt.Errorf("parser error: annotation @%v of function %v can only be used on parameters", key, daFunq.FuncName)
+		}
+		if err := rule.check(annotations.function[key], nil); err != nil {
+			return fmt.Errorf("parser error: annotation @%v of function %v %v", key, daFunq.FuncName, err)
+		}
+	}
+	if len(annotations.function) > 0 {
+		daFunq.Annotations = annotations.function
+	}
+	for side, params := range [][]definitions.QType{daFunq.Inputs, daFunq.Outputs} {
+		for i := range params {
+			param := &params[i]
+			paramAnnotations := annotations.params[param.TypeName]
+			for _, key := range sortedKeys(paramAnnotations) {
+				rule, known := annotationRules[key]
+				if !known {
+					return unknownAnnotationError(key)
+				}
+				if !rule.onParameter {
+					return fmt.Errorf("parser error: annotation @%v of parameter %v can only be used on functions", key, param.TypeName)
+				}
+				if rule.inputsOnly && side == 1 {
+					return fmt.Errorf("parser error: annotation @%v of output %v can only be used on inputs", key, param.TypeName)
+				}
+				if err := rule.check(paramAnnotations[key], param); err != nil {
+					return fmt.Errorf("parser error: annotation @%v of parameter %v %v", key, param.TypeName, err)
+				}
+			}
+			if len(paramAnnotations) > 0 {
+				param.Annotations = paramAnnotations
+			}
+		}
+	}
+	return nil
+}
+
+func unknownAnnotationError(key string) error {
+	var known []string
+	for name := range annotationRules {
+		known = append(known, "@"+name)
+	}
+	sort.Strings(known)
+	return fmt.Errorf("parser error: No such annotation @%v available, try %v instead", key, strings.Join(known, ", "))
+}
+
+func sortedKeys(values map[string]string) []string {
+	var keys []string
+	for key := range values {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
@@ -0,0 +1,68 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	def "github.com/qtumproject/simple-abi/definitions"
+)
+
+func TestParseAnnotations(t *testing.T) {
+	var annotationInputs = []struct {
+		input  string
+		output def.QFunc
+	}{
+		{
+			"a:uint64 myFunction:fn @gas(50000) -> void",
+			def.QFunc{FuncName: "myFunction", Annotations: map[string]string{"gas": "50000"}, Inputs: []def.QType{{TypeName: "a", Type: "uint64"}}},
+		},
+		{
+			"data:uint8[] @maxlen(256) from:@sender myFunction:fn:payable @deprecated(\"use myFunction2 -> v2\") -> ok:uint8 @deprecated()",
+			def.QFunc{
+				FuncName:    "myFunction",
+				Payable:     true,
+				Annotations: map[string]string{"deprecated": "use myFunction2 -> v2"},
+				Inputs:      []def.QType{{TypeName: "data", Type: "uint8[]", Annotations: map[string]string{"maxlen": "256"}}, {TypeName: "from", Type: "@sender"}},
+				Outputs:     []def.QType{{TypeName: "ok", Type: "uint8", Annotations: map[string]string{"deprecated": ""}}},
+			},
+		},
+	}
+	for _, tt := range annotationInputs {
+		component, returned, err := parseLine(tt.input, 0)
+		if err != nil {
+			t.Errorf("Unexpected error occurred parsing %v: %v", tt.input, err)
+			continue
+		}
+		if component != functionComponent || !cmp.Equal(returned, tt.output) {
+			t.Errorf("Expected %v, got %v", tt.output, returned)
+		}
+	}
+}
+
+func TestParseAnnotationErrors(t *testing.T) {
+	var annotationInputs = []struct {
+		input string
+		err   string
+	}{
+		{"a:uint64 myFunction:fn @price(5) -> void", "parser error: No such annotation @price available, try @deprecated, @gas, @maxlen instead"},
+		{"a:uint64 myFunction:fn @gas(lots) -> void", "parser error: annotation @gas of function myFunction needs a positive integer, got \"lots\""},
+		{"a:uint64 @gas(5) myFunction:fn -> void", "parser error: annotation @gas of parameter a can only be used on functions"},
+		{"a:uint64 myFunction:fn @maxlen(5) -> void", "parser error: annotation @maxlen of function myFunction can only be used on parameters"},
+		{"a:uint64 @maxlen(5) myFunction:fn -> void", "parser error: annotation @maxlen of parameter a can only be used on dynamic arrays"},
+		{"void myFunction:fn -> a:uint8[] @maxlen(5)", "parser error: annotation @maxlen of output a can only be used on inputs"},
+		{"@gas(5) a:uint64 myFunction:fn -> void", "parser error: annotation @gas(5) has to follow a function name or a parameter"},
+		{"a:uint64 myFunction:fn -> @gas(5) void", "parser error: annotation @gas(5) has to follow a function name or a parameter"},
+		{"a:uint64 myFunction:fn @gas(5) @gas(6) -> void", "parser error: annotation @gas used more than once on the same function or parameter"},
+		{"a:uint64 myFunction:fn @gas(5 -> void", "parser error: Invalid annotation @gas(5: needs to be formatted as @key(value)"},
+		{"a:uint64 myFunction:fn @deprecated(use v2) -> void", "parser error: Invalid annotation @deprecated(use: needs to be formatted as @key(value)"},
+		{`a:uint64 myFunction:fn @deprecated("use */ foo") -> void`, `parser error: Invalid annotation @deprecated("use */ foo"): the value can't contain */, which would end the comments of the generated code`},
+		{`a:uint64 @deprecated("see C:\\coins") myFunction:fn -> void`, `parser error: Invalid annotation @deprecated("see C:\\coins"): the value can't contain \, which would end the comments of the generated code`},
+	}
+	for _, tt := range annotationInputs {
+		_, _, err := parseLine(tt.input, 0)
+		if err == nil || err.Error() != tt.err {
+			t.Errorf("Expected error %v parsing %v, got %v", tt.err, tt.input, err)
+		}
+	}
+}
@@ -0,0 +1,146 @@
+package parser
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/qtumproject/simple-abi/definitions"
+)
+
+// reservedWords are the keywords of languages whose generators use names from an .abi file as they are, as
+// parameter and variable names, so none of them can be used. The other generators append an underscore to their
+// own keywords instead, as names such as type, new or from are fine in the C code
+var reservedWords = map[string][]string{
+	"C": {
+		"auto", "break", "case", "char", "const", "continue", "default", "do", "double", "else", "enum", "extern",
+		"float", "for", "goto", "if", "inline", "int", "long", "register", "restrict", "return", "short", "signed",
+		"sizeof", "static", "struct", "switch", "typedef", "union", "unsigned", "void", "volatile", "while",
+		"_Alignas", "_Alignof", "_Atomic", "_Bool", "_Complex", "_Generic", "_Imaginary", "_Noreturn",
+		"_Static_assert", "_Thread_local", "bool", "true", "false", "NULL",
+	},
+}
+
+// generatedNames are names the generated code declares next to the ones from the .abi file
+var generatedNames = map[string]string{
+	"__address":           "the address parameter of generated encoding functions",
+	"__options":           "the options parameter of generated encoding functions",
+	"r":                   "the call result in generated encoding functions",
+	"fn":                  "the function ID in the generated dispatcher",
+	"malloc":              "a C library function called by generated code",
+	"free":                "a C library function called by generated code",
+	"memcmp":              "a C library function called by generated code",
+	"memcpy":              "a C library function called by generated code",
+	"memset":              "a C library function called by generated code",
+	"int8_t":              "a C type used by generated code",
+	"int16_t":             "a C type used by generated code",
+	"int32_t":             "a C type used by generated code",
+	"int64_t":             "a C type used by generated code",
+	"uint8_t":             "a C type used by generated code",
+	"uint16_t":            "a C type used by generated code",
+	"uint32_t":            "a C type used by generated code",
+	"uint64_t":            "a C type used by generated code",
+	"size_t":              "a C type used by generated code",
+	"UniversalAddress":    "a C type used by generated code",
+	"UniversalAddressABI": "a C type used by generated code",
+	"QtumCallOptions":     "a C type used by generated code",
+	"QtumCallResult":      "a C type used by generated code",
+	"QtumExec":            "a C type used by generated code",
+}
+
+// validateIdentifier checks that a contract, function, parameter or other name can be used in generated code,
+// where optionally tells which function the name belongs to, e.g. " in function transfer"
+func validateIdentifier(name string, kind string, where ...string) error {
+	location := strings.Join(where, "")
+	if !isValidIdentifier(name) {
+		return fmt.Errorf("parser error: Invalid %v name %q%v, names may only contain letters, digits and underscores and can't start with a digit", kind, name, location)
+	}
+	if strings.HasPrefix(name, "__") {
+		return fmt.Errorf("parser error: %v name %q%v starts with \"__\", which is reserved for generated code, pick another name", kind, name, location)
+	}
+	if strings.HasPrefix(name, "qtum") {
+		return fmt.Errorf("parser error: %v name %q%v starts with \"qtum\", which is reserved for the qtum library, pick another name", kind, name, location)
+	}
+	var languages []string
+	for language := range reservedWords {
+		languages = append(languages, language)
+	}
+	sort.Strings(languages)
+	for _, language := range languages {
+		for _, word := range reservedWords[language] {
+			if name == word {
+				return fmt.Errorf("parser error: %v name %q%v is a reserved word in %v, pick another name", kind, name, location, language)
+			}
+		}
+	}
+	return nil
+}
+
+// validateSignatureNames checks the function and parameter names of a function, parameters share one namespace
+// in generated code so they have to be unique and can't clash with the names the generator adds
+func validateSignatureNames(daFunq definitions.QFunc) error {
+	if err := validateIdentifier(daFunq.FuncName, "function"); err != nil {
+		return err
+	}
+	params := make(map[string]definitions.QType)
+	for _, param := range append(append([]definitions.QType{}, daFunq.Inputs...), daFunq.Outputs...) {
+		if err := validateIdentifier(param.TypeName, "parameter", " in function "+daFunq.FuncName); err != nil {
+			return err
+		}
+		if use, exists := generatedNames[param.TypeName]; exists {
+			return fmt.Errorf("parser error: parameter name %q in function %v clashes with %v, pick another name", param.TypeName, daFunq.FuncName, use)
+		}
+		if _, exists := params[param.TypeName]; exists {
+			return fmt.Errorf("parser error: parameter name %q is used more than once in function %v", param.TypeName, daFunq.FuncName)
+		}
+		params[param.TypeName] = param
+	}
+	// dynamic arrays are passed along with a <name>_sz length parameter
+	for name, param := range params {
+		if strings.HasSuffix(param.Type, "[]") {
+			if _, exists := params[name+"_sz"]; exists {
+				return fmt.Errorf("parser error: parameter name %q in function %v clashes with the length parameter generated for array %v, pick another name", name+"_sz", daFunq.FuncName, name)
+			}
+		}
+	}
+	return nil
+}
+
+// validateContractNames checks the function and parameter names of a contract against the names the generated
+// code derives from its roles, storage slots, constants and functions
+func validateContractNames(builtInterface definitions.QInterfaceBuilder) error {
+	contract := builtInterface.ContractName
+	functions := make(map[string]string)
+	for _, role := range builtInterface.Roles {
+		functions["role_"+role] = "the accessor of role " + role
+	}
+	for _, slot := range builtInterface.Storage {
+		accessors := []string{"get", "set"}
+		if strings.HasPrefix(slot.Type, "map<") {
+			accessors = append(accessors, "has", "delete")
+		}
+		for _, accessor := range accessors {
+			functions["storage_"+accessor+"_"+slot.TypeName] = "an accessor of storage slot " + slot.TypeName
+		}
+	}
+	params := make(map[string]string)
+	for _, constant := range builtInterface.Constants {
+		params[contract+"_"+constant.Name] = "the C macro of constant " + constant.Name
+	}
+	for _, function := range builtInterface.Functions {
+		params["ID_"+contract+"_"+function.CName()] = "the ID of function " + function.FuncName
+		params["encode_"+function.CName()] = "the Python function encoding calls to " + function.FuncName
+		params["decode_"+function.CName()] = "the Python function decoding the outputs of " + function.FuncName
+	}
+	for _, function := range builtInterface.Functions {
+		if use, exists := functions[function.CName()]; exists {
+			return fmt.Errorf("parser error: function name %q clashes with %v, pick another name", function.FuncName, use)
+		}
+		for _, param := range append(append([]definitions.QType{}, function.Inputs...), function.Outputs...) {
+			if use, exists := params[param.TypeName]; exists {
+				return fmt.Errorf("parser error: parameter name %q in function %v clashes with %v, pick another name", param.TypeName, function.FuncName, use)
+			}
+		}
+	}
+	return nil
+}
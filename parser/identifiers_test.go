@@ -0,0 +1,134 @@
+package parser
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseIdentifierErrors(t *testing.T) {
+	var identifierInputs = []struct {
+		input string
+		err   string
+	}{
+		{
+			"int:uint8 myFunction:fn -> void",
+			"parser error: parameter name \"int\" in function myFunction is a reserved word in C, pick another name",
+		},
+		{
+			"a:uint8 switch:fn -> void",
+			"parser error: function name \"switch\" is a reserved word in C, pick another name",
+		},
+		{
+			"fn:uint8 myFunction:fn -> void",
+			"parser error: parameter name \"fn\" in function myFunction clashes with the function ID in the generated dispatcher, pick another name",
+		},
+		{
+			"r:uint8 myFunction:fn -> void",
+			"parser error: parameter name \"r\" in function myFunction clashes with the call result in generated encoding functions, pick another name",
+		},
+		{
+			"__address:uniaddress myFunction:fn -> void",
+			"parser error: parameter name \"__address\" in function myFunction starts with \"__\", which is reserved for generated code, pick another name",
+		},
+		{
+			"qtumExec:uint8 myFunction:fn -> void",
+			"parser error: parameter name \"qtumExec\" in function myFunction starts with \"qtum\", which is reserved for the qtum library, pick another name",
+		},
+		{
+			"foo:uint8 myFunction:fn -> foo:uint32",
+			"parser error: parameter name \"foo\" is used more than once in function myFunction",
+		},
+		{
+			"foo:uint8[] foo_sz:uint32 myFunction:fn -> void",
+			"parser error: parameter name \"foo_sz\" in function myFunction clashes with the length parameter generated for array foo, pick another name",
+		},
+		{
+			"9lives:uint8 myFunction:fn -> void",
+			"parser error: Invalid parameter name \"9lives\" in function myFunction, names may only contain letters, digits and underscores and can't start with a digit",
+		},
+		{
+			"uint8_t:uint8 myFunction:fn -> void",
+			"parser error: parameter name \"uint8_t\" in function myFunction clashes with a C type used by generated code, pick another name",
+		},
+		{
+			":name=struct",
+			"parser error: contract name \"struct\" is a reserved word in C, pick another name",
+		},
+	}
+
+	for _, test := range identifierInputs {
+		_, _, err := parseLine(test.input, 0)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("Expected error %v, got %v", test.err, err)
+		}
+	}
+
+	for _, input := range []string{
+		// foo_sz is only generated for arrays
+		"foo:uint8 foo_sz:uint32 myFunction:fn -> void",
+		// Rust, C++ and Go keywords are escaped by their generators
+		"self:uint8 match:fn -> mut:uint8",
+		"new:uint8 class:fn -> delete:uint8",
+		"range:uint8 type:fn -> map:uint8",
+		// Go and Python escape the names their generated code uses
+		"error:uint8 nil:uint8 myFunction:fn -> _options:uint8",
+		"self:uint8 Stack:uint8 myFunction:fn -> void",
+	} {
+		if _, _, err := parseLine(input, 0); err != nil {
+			t.Errorf("Expected no error parsing %q, got %v", input, err)
+		}
+	}
+}
+
+func TestValidateContractNames(t *testing.T) {
+	var contractInputs = []struct {
+		abi string
+		err string
+	}{
+		{
+			":role=admin\nvoid role_admin:fn -> void",
+			"parser error: function name \"role_admin\" clashes with the accessor of role admin, pick another name",
+		},
+		{
+			":storage\nbalance:uint64\nvoid storage_set_balance:fn -> void",
+			"parser error: function name \"storage_set_balance\" clashes with an accessor of storage slot balance, pick another name",
+		},
+		{
+			":storage\nbalances:map<uniaddress,uint64>\nvoid storage_has_balances:fn -> void",
+			"parser error: function name \"storage_has_balances\" clashes with an accessor of storage slot balances, pick another name",
+		},
+		{
+			":const=KEY_LEN uint8 4\nToken_KEY_LEN:uint8 transfer:fn -> void",
+			"parser error: parameter name \"Token_KEY_LEN\" in function transfer clashes with the C macro of constant KEY_LEN, pick another name",
+		},
+		{
+			"void transfer:fn -> ID_Token_transfer:uint32",
+			"parser error: parameter name \"ID_Token_transfer\" in function transfer clashes with the ID of function transfer, pick another name",
+		},
+		{
+			"encode_transfer:uint8 transfer:fn -> void",
+			"parser error: parameter name \"encode_transfer\" in function transfer clashes with the Python function encoding calls to transfer, pick another name",
+		},
+	}
+	for _, test := range contractInputs {
+		path := filepath.Join(t.TempDir(), "Token.abi")
+		if err := ioutil.WriteFile(path, []byte(":name=Token\n"+test.abi+"\n"), 0666); err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		_, err := Parse(path, false)
+		if err == nil || err.Error() != test.err {
+			t.Errorf("Expected error %v parsing %q, got %v", test.err, test.abi, err)
+		}
+	}
+
+	// names derived from the roles, storage slots, constants and functions of another contract are fine
+	path := filepath.Join(t.TempDir(), "Token.abi")
+	abi := ":name=Token\n:role=admin\nvoid role_owner:fn -> void\nVault_KEY_LEN:uint8 encode_mint:uint8 transfer:fn -> void\n"
+	if err := ioutil.WriteFile(path, []byte(abi), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if _, err := Parse(path, false); err != nil {
+		t.Errorf("Unexpected error occurred: %v", err)
+	}
+}
@@ -0,0 +1,92 @@
+package parser
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// maxLineLength is the longest physical line the parser accepts, well above bufio.Scanner's 64KB default
+const maxLineLength = 16 * 1024 * 1024
+
+// lineReader reads the logical lines of an .abi file: a line ending in a backslash is continued on the next one,
+// and every line comes out normalized by normalizeLine
+type lineReader struct {
+	scanner *bufio.Scanner
+	// number of physical lines read so far
+	read int
+}
+
+func newLineReader(r io.Reader) *lineReader {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	return &lineReader{scanner: scanner}
+}
+
+// next returns the next logical line together with the number of the physical line it starts on,
+// it returns false once there are no lines left or reading failed
+func (l *lineReader) next() (string, int, bool, error) {
+	var joined []string
+	number := l.read
+	for l.scanner.Scan() {
+		raw := l.scanner.Text()
+		if l.read == 0 {
+			// files saved by some Windows editors start with a UTF-8 byte order mark
+			raw = strings.TrimPrefix(raw, "\uFEFF")
+		}
+		l.read++
+		line := normalizeLine(raw)
+		if joined != nil && strings.HasPrefix(line, "#") {
+			// comments can sit between the lines of a continued signature
+			continue
+		}
+		if !strings.HasSuffix(line, "\\") || strings.HasPrefix(line, "#") {
+			return strings.Join(append(joined, line), " "), number, true, nil
+		}
+		if continued := strings.TrimSpace(strings.TrimSuffix(line, "\\")); continued != "" {
+			joined = append(joined, continued)
+		}
+	}
+	if err := l.scanner.Err(); err != nil {
+		if err == bufio.ErrTooLong {
+			return "", number, false, fmt.Errorf("parser error: line %v is longer than %v bytes", l.read, maxLineLength)
+		}
+		return "", number, false, err
+	}
+	if joined != nil {
+		return "", number, false, fmt.Errorf("parser error: line continuation at line %v is not followed by another line", l.read-1)
+	}
+	return "", number, false, nil
+}
+
+// normalizeLine strips a trailing carriage return and an inline # comment from a line,
+// and collapses tabs and repeated spaces into single spaces, except inside double quotes.
+// Whole line comments and ## doc comments are only trimmed so their text stays intact
+func normalizeLine(input string) string {
+	input = strings.TrimSpace(strings.TrimSuffix(input, "\r"))
+	if strings.HasPrefix(input, "#") {
+		return input
+	}
+	var normalized strings.Builder
+	var quoted, space bool
+	for _, char := range input {
+		switch {
+		case char == '"':
+			quoted = !quoted
+		case quoted:
+		case char == '#' && space:
+			// a # after white space starts a comment running to the end of the line
+			return strings.TrimSpace(normalized.String())
+		case char == ' ' || char == '\t':
+			if !space {
+				normalized.WriteRune(' ')
+			}
+			space = true
+			continue
+		}
+		space = false
+		normalized.WriteRune(char)
+	}
+	return normalized.String()
+}
@@ -0,0 +1,141 @@
+package parser
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/google/go-cmp/cmp"
+
+	def "github.com/qtumproject/simple-abi/definitions"
+)
+
+func TestNormalizeLine(t *testing.T) {
+	var normalizeInputs = []struct {
+		input  string
+		output string
+	}{
+		{"a:uint8\tmyFunction:fn\t->\tvoid", "a:uint8 myFunction:fn -> void"},
+		{"  a:uint8   myFunction:fn  ->   void  ", "a:uint8 myFunction:fn -> void"},
+		{"a:uint8 myFunction:fn -> void\r", "a:uint8 myFunction:fn -> void"},
+		{"a:uint8 myFunction:fn -> void # moves the coins", "a:uint8 myFunction:fn -> void"},
+		{"a:uint8 myFunction:fn -> void\t# moves the coins", "a:uint8 myFunction:fn -> void"},
+		{":description=\"Token  #1\" # the first one", ":description=\"Token  #1\""},
+		{":implements=Other(./other.abi#v2)", ":implements=Other(./other.abi#v2)"},
+		{"\t## doc  comment ", "## doc  comment"},
+		{"   ", ""},
+	}
+	for _, tt := range normalizeInputs {
+		if output := normalizeLine(tt.input); output != tt.output {
+			t.Errorf("Expected %q to normalize to %q, got %q", tt.input, tt.output, output)
+		}
+	}
+}
+
+func TestLineReader(t *testing.T) {
+	const contents = "\uFEFF:name=Test\r\n" +
+		"a:uint8 \\\r\n" +
+		"# a comment in between\r\n" +
+		"\tb:uint8 myFunction:fn \\\r\n" +
+		"  -> c:uint8\r\n" +
+		"\r\n" +
+		"void other:fn -> void\r\n"
+	reader := newLineReader(strings.NewReader(contents))
+	var lines []string
+	var numbers []int
+	for {
+		line, number, ok, err := reader.next()
+		if err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		if !ok {
+			break
+		}
+		lines = append(lines, line)
+		numbers = append(numbers, number)
+	}
+	wantLines := []string{":name=Test", "a:uint8 b:uint8 myFunction:fn -> c:uint8", "", "void other:fn -> void"}
+	if !cmp.Equal(lines, wantLines) {
+		t.Errorf("Expected lines %q, got %q", wantLines, lines)
+	}
+	wantNumbers := []int{0, 1, 5, 6}
+	if !cmp.Equal(numbers, wantNumbers) {
+		t.Errorf("Expected line numbers %v, got %v", wantNumbers, numbers)
+	}
+
+	reader = newLineReader(strings.NewReader(":name=Test\na:uint8 \\\n"))
+	reader.next()
+	want := "parser error: line continuation at line 1 is not followed by another line"
+	if _, _, _, err := reader.next(); err == nil || err.Error() != want {
+		t.Errorf("Expected error %v, got %v", want, err)
+	}
+}
+
+func TestParseLexicalVariations(t *testing.T) {
+	dir, err := ioutil.TempDir("", "simpleabi")
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	defer os.RemoveAll(dir)
+
+	// a signature with enough parameters to be longer than bufio.Scanner's default 64KB token size
+	var params []string
+	var inputs []def.QType
+	for i := 0; len(strings.Join(params, " ")) < 70*1024; i++ {
+		name := "param" + strings.Repeat("x", 20) + string(rune('a'+i%26)) + strings.Repeat("y", i/26)
+		params = append(params, name+":uint64")
+		inputs = append(inputs, def.QType{TypeName: name, Type: "uint64"})
+	}
+
+	var lexicalInputs = []struct {
+		name     string
+		contents string
+		function def.QFunc
+	}{
+		{
+			"crlf and bom",
+			"\uFEFF:name=Test\r\na:uint8 myFunction:fn -> b:uint8\r\n",
+			def.QFunc{FuncName: "myFunction", Inputs: []def.QType{{TypeName: "a", Type: "uint8"}}, Outputs: []def.QType{{TypeName: "b", Type: "uint8"}}},
+		},
+		{
+			"tabs and spaces",
+			":name=Test\n\ta:uint8\t\tmyFunction:fn  ->   b:uint8  \n",
+			def.QFunc{FuncName: "myFunction", Inputs: []def.QType{{TypeName: "a", Type: "uint8"}}, Outputs: []def.QType{{TypeName: "b", Type: "uint8"}}},
+		},
+		{
+			"inline comments",
+			":name=Test # the test contract\na:uint8 myFunction:fn -> b:uint8 # does things\n",
+			def.QFunc{FuncName: "myFunction", Inputs: []def.QType{{TypeName: "a", Type: "uint8"}}, Outputs: []def.QType{{TypeName: "b", Type: "uint8"}}},
+		},
+		{
+			"line continuation",
+			":name=Test\na:uint8 \\\n  b:uint8 \\\n  myFunction:fn -> c:uint8\n",
+			def.QFunc{FuncName: "myFunction", Inputs: []def.QType{{TypeName: "a", Type: "uint8"}, {TypeName: "b", Type: "uint8"}}, Outputs: []def.QType{{TypeName: "c", Type: "uint8"}}},
+		},
+		{
+			"long line",
+			":name=Test\n" + strings.Join(params, " ") + " myFunction:fn -> void\n",
+			def.QFunc{FuncName: "myFunction", Inputs: inputs},
+		},
+	}
+	for _, tt := range lexicalInputs {
+		location := filepath.Join(dir, "Test.abi")
+		if err := ioutil.WriteFile(location, []byte(tt.contents), 0666); err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		builders, err := Parse(location, false)
+		if err != nil {
+			t.Errorf("%v: Unexpected error occurred: %v", tt.name, err)
+			continue
+		}
+		if len(builders) != 1 || builders[0].ContractName != "Test" {
+			t.Errorf("%v: Expected a single contract named Test, got %v", tt.name, builders)
+			continue
+		}
+		if !cmp.Equal(builders[0].Functions, []def.QFunc{tt.function}) {
+			t.Errorf("%v: Expected functions %v, got %v", tt.name, []def.QFunc{tt.function}, builders[0].Functions)
+		}
+	}
+}
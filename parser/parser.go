@@ -1,12 +1,12 @@
 package parser
 
 import (
-	"bufio"
 	"fmt"
 	"net/http"
 	"net/url"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
 
 	"github.com/qtumproject/simple-abi/definitions"
@@ -18,96 +18,558 @@ type component int
 const (
 	nameComponent component = iota
 	interfaceComponent
+	roleComponent
+	abstractComponent
+	attributeComponent
+	constComponent
+	storageComponent
+	storageSlotComponent
 	functionComponent
 	commentComponent
+	docComponent
 	errorComponent
 )
 
-// Parse opens up a file and returns a QInterfaceBuilder for building of templates
-func Parse(location string, isURL bool) (definitions.QInterfaceBuilder, error) {
-	var scanner *bufio.Scanner
+// metadataAttributes are the contract level attributes stored in QInterfaceBuilder.Attributes,
+// custom attributes are allowed as long as they are prefixed with "x-"
+var metadataAttributes = []string{"version", "author", "license", "description"}
+
+// attribute is a metadata attribute parsed from a line such as :version=0.2.0
+type attribute struct {
+	key   string
+	value string
+}
+
+// Parse opens up a file and returns a QInterfaceBuilder for every contract and interface declared in it,
+// in the order they are declared
+func Parse(location string, isURL bool) ([]definitions.QInterfaceBuilder, error) {
+	var reader *lineReader
 	if isURL {
 		response, err := http.Get(location)
 		if err != nil {
-			return definitions.QInterfaceBuilder{}, err
+			return nil, err
 		} else {
 			defer response.Body.Close()
-			scanner = bufio.NewScanner(response.Body)
+			reader = newLineReader(response.Body)
 		}
 	} else {
 		file, err := os.Open(location)
 		if err != nil {
-			return definitions.QInterfaceBuilder{}, err
+			return nil, err
 		}
 		defer file.Close()
-		scanner = bufio.NewScanner(file)
+		reader = newLineReader(file)
 	}
 
-	qFuncSet := make(map[string]definitions.QFunc)
-	var counter int
-	var builtInterface definitions.QInterfaceBuilder
-	for scanner.Scan() {
-		component, returned, err := parseLine(scanner.Text(), counter)
+	var builtInterfaces []definitions.QInterfaceBuilder
+	var current *block
+	var inStorage bool
+	var docLines []string
+	for {
+		line, counter, ok, err := reader.next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			break
+		}
+		var component component
+		var returned interface{}
+		if inStorage && isStorageSlotLine(line) {
+			component = storageSlotComponent
+			returned, err = parseStorageSlot(line, counter)
+		} else {
+			component, returned, err = parseLine(line, counter)
+			if component != commentComponent && component != docComponent {
+				inStorage = false
+			}
+		}
+		if err != nil {
+			return nil, err
+		}
+		if current == nil || ((component == nameComponent || component == abstractComponent) && current.named()) {
+			// every :name= or :interface= after the first one starts a new block
+			if current != nil {
+				builtInterface, err := current.finish()
+				if err != nil {
+					return nil, err
+				}
+				builtInterfaces = append(builtInterfaces, builtInterface)
+			}
+			current = newBlock()
+		}
+		builtInterface := &current.builtInterface
 		switch component {
-		case nameComponent:
-			if builtInterface.ContractName != "" {
-				return definitions.QInterfaceBuilder{}, fmt.Errorf("attempted to declare multiple names for contract %v; only one contract name allowed per instance", builtInterface.ContractName)
+		case nameComponent, abstractComponent:
+			for _, existing := range builtInterfaces {
+				if existing.ContractName == returned.(string) {
+					return nil, fmt.Errorf("parser error: %v declared more than once at line %v", existing.ContractName, counter)
+				}
 			}
 			builtInterface.ContractName = returned.(string)
+			builtInterface.IsInterface = component == abstractComponent
 		case functionComponent:
-			builtInterface.Functions = append(builtInterface.Functions, returned.(definitions.QFunc))
+			daFunq := returned.(definitions.QFunc)
+			if err := applyDoc(&daFunq, docLines); err != nil {
+				return nil, fmt.Errorf("%v at line %v", err, counter)
+			}
+			builtInterface.Functions = append(builtInterface.Functions, daFunq)
 		case commentComponent:
-			counter++
+			continue
+		case docComponent:
+			docLines = append(docLines, returned.(string))
 			continue
 		case errorComponent:
-			return definitions.QInterfaceBuilder{}, err
+			return nil, err
 		case interfaceComponent:
-			err := implementInterface(qFuncSet, returned.(string))
+			err := current.implementInterface(builtInterfaces, returned.(string))
 			if err != nil {
-				return definitions.QInterfaceBuilder{}, err
+				return nil, err
+			}
+		case constComponent:
+			if err := addConstant(builtInterface, returned.(definitions.QConst)); err != nil {
+				return nil, fmt.Errorf("%v at line %v", err, counter)
+			}
+		case roleComponent:
+			for _, role := range builtInterface.Roles {
+				if role == returned.(string) {
+					return nil, fmt.Errorf("parser error: role %v declared more than once at line %v", role, counter)
+				}
 			}
+			builtInterface.Roles = append(builtInterface.Roles, returned.(string))
+		case attributeComponent:
+			attr := returned.(attribute)
+			if _, exists := builtInterface.Attributes[attr.key]; exists {
+				return nil, fmt.Errorf("parser error: attribute %v declared more than once at line %v", attr.key, counter)
+			}
+			if builtInterface.Attributes == nil {
+				builtInterface.Attributes = make(map[string]string)
+			}
+			builtInterface.Attributes[attr.key] = attr.value
+		case storageComponent:
+			inStorage = true
+		case storageSlotComponent:
+			slot := returned.(definitions.QType)
+			for _, existing := range builtInterface.Storage {
+				if existing.TypeName == slot.TypeName {
+					return nil, fmt.Errorf("parser error: storage slot %v declared more than once at line %v", slot.TypeName, counter)
+				}
+			}
+			slot.Doc = strings.Join(docLines, "\n")
+			builtInterface.Storage = append(builtInterface.Storage, slot)
+		}
+		// doc comments only attach to the line directly following them
+		docLines = nil
+	}
+	if current != nil {
+		builtInterface, err := current.finish()
+		if err != nil {
+			return nil, err
 		}
-		counter++
+		builtInterfaces = append(builtInterfaces, builtInterface)
 	}
-	for _, y := range qFuncSet {
-		builtInterface.Functions = append(builtInterface.Functions, y)
+	return builtInterfaces, nil
+}
+
+// block is a contract or interface that is still being parsed
+type block struct {
+	builtInterface definitions.QInterfaceBuilder
+	// functions pulled in through :implements keyed by their mangled name, added once the block is finished
+	qFuncSet map[string]definitions.QFunc
+	// mangled names of the imported functions in the order they were imported
+	qFuncOrder []string
+	// abstract interfaces the block has to conform to once it is finished
+	required []definitions.QInterfaceBuilder
+}
+
+func newBlock() *block {
+	return &block{qFuncSet: make(map[string]definitions.QFunc)}
+}
+
+// named reports whether the block already got its :name= or :interface= declaration
+func (b *block) named() bool {
+	return b.builtInterface.ContractName != ""
+}
+
+// finish adds the implemented functions to the block and validates it
+func (b *block) finish() (definitions.QInterfaceBuilder, error) {
+	builtInterface := b.builtInterface
+	if err := resolveArrayLengths(&builtInterface); err != nil {
+		return definitions.QInterfaceBuilder{}, err
+	}
+	declared := make(map[string]bool)
+	for _, function := range builtInterface.Functions {
+		declared[function.MangledName()] = true
+	}
+	for _, key := range b.qFuncOrder {
+		// functions declared by the contract itself take precedence over imported ones
+		if !declared[key] {
+			builtInterface.Functions = append(builtInterface.Functions, b.qFuncSet[key])
+		}
+	}
+	if err := markOverloads(&builtInterface); err != nil {
+		return definitions.QInterfaceBuilder{}, err
+	}
+	for _, required := range b.required {
+		if err := checkConformance(builtInterface, required); err != nil {
+			return definitions.QInterfaceBuilder{}, err
+		}
+		builtInterface.Implements = append(builtInterface.Implements, required.ContractName)
+	}
+	if err := validateRoles(builtInterface); err != nil {
+		return definitions.QInterfaceBuilder{}, err
+	}
+	if err := validateContractNames(builtInterface); err != nil {
+		return definitions.QInterfaceBuilder{}, err
 	}
 	return builtInterface, nil
 }
 
-func implementInterface(qFuncSet map[string]definitions.QFunc, interfaceField string) error {
-	interfaceFilenames := strings.Split(interfaceField, ",")
-	for _, interFilename := range interfaceFilenames {
+// markOverloads flags functions sharing a name as overloaded so their C symbols get mangled. Overloads have
+// to differ in their input types, as the mangled names and the callers can't tell them apart otherwise
+func markOverloads(builtInterface *definitions.QInterfaceBuilder) error {
+	byName := make(map[string]int)
+	byMangledName := make(map[string]bool)
+	for _, function := range builtInterface.Functions {
+		if byMangledName[function.MangledName()] {
+			return fmt.Errorf("parser error: function %v of %v is declared more than once with the same input types, overloads have to differ in their inputs", function.FuncName, builtInterface.ContractName)
+		}
+		byMangledName[function.MangledName()] = true
+		byName[function.FuncName]++
+	}
+	for i := range builtInterface.Functions {
+		builtInterface.Functions[i].Overloaded = byName[builtInterface.Functions[i].FuncName] > 1
+	}
+	return nil
+}
 
-		location, err := getInterfaceLocation(interFilename)
+// checkConformance verifies that a contract declares every function required by an abstract interface,
+// with the same input and output types and the same payable modifier
+func checkConformance(builtInterface definitions.QInterfaceBuilder, required definitions.QInterfaceBuilder) error {
+	for _, requiredFunc := range required.Functions {
+		var candidates []string
+		for _, function := range builtInterface.Functions {
+			if function.FuncName != requiredFunc.FuncName {
+				continue
+			}
+			if function.CanonicalSignature() == requiredFunc.CanonicalSignature() {
+				candidates = nil
+				break
+			}
+			candidates = append(candidates, function.CanonicalSignature())
+		}
+		if len(candidates) > 0 {
+			return fmt.Errorf("parser error: %v does not implement %v: function %v is declared as %q but %v requires %q", builtInterface.ContractName, required.ContractName, requiredFunc.FuncName, strings.Join(candidates, "\", \""), required.ContractName, requiredFunc.CanonicalSignature())
+		}
+		if !hasFunction(builtInterface, requiredFunc) {
+			return fmt.Errorf("parser error: %v does not implement %v: missing function %q", builtInterface.ContractName, required.ContractName, requiredFunc.CanonicalSignature())
+		}
+	}
+	return nil
+}
+
+func hasFunction(builtInterface definitions.QInterfaceBuilder, requiredFunc definitions.QFunc) bool {
+	for _, function := range builtInterface.Functions {
+		if function.CanonicalSignature() == requiredFunc.CanonicalSignature() {
+			return true
+		}
+	}
+	return false
+}
+
+// addConstant adds a constant to the contract, a constant may only be declared again
+// (e.g. by an implemented interface) if it has the same type and value
+func addConstant(builtInterface *definitions.QInterfaceBuilder, constant definitions.QConst) error {
+	for _, existing := range builtInterface.Constants {
+		if existing.Name == constant.Name {
+			if existing != constant {
+				return fmt.Errorf("parser error: conflicting declarations of constant %v", constant.Name)
+			}
+			return nil
+		}
+	}
+	builtInterface.Constants = append(builtInterface.Constants, constant)
+	return nil
+}
+
+// resolveArrayLengths sets the Length of every fixed size array such as uint8[32] or uint8[KEY_LEN],
+// which can only be done once every constant of the contract and its interfaces is known
+func resolveArrayLengths(builtInterface *definitions.QInterfaceBuilder) error {
+	for i := range builtInterface.Functions {
+		daFunq := &builtInterface.Functions[i]
+		for _, params := range [][]definitions.QType{daFunq.Inputs, daFunq.Outputs} {
+			for j := range params {
+				if !isValidFixedArray(params[j].Type) {
+					continue
+				}
+				lengthText := params[j].Type[strings.Index(params[j].Type, "[")+1 : len(params[j].Type)-1]
+				length, err := strconv.Atoi(lengthText)
+				if err != nil {
+					found := false
+					for _, constant := range builtInterface.Constants {
+						if constant.Name == lengthText {
+							length, err = strconv.Atoi(constant.Value)
+							found = err == nil
+						}
+					}
+					if !found {
+						return fmt.Errorf("parser error: array length %v of %v in function %v is neither a number nor a declared constant", lengthText, params[j].TypeName, daFunq.FuncName)
+					}
+				}
+				if length <= 0 {
+					return fmt.Errorf("parser error: array length %v of %v in function %v must be positive", lengthText, params[j].TypeName, daFunq.FuncName)
+				}
+				params[j].Length = length
+			}
+		}
+	}
+	return nil
+}
+
+// parseConstant parses the value of a constant attribute such as :const=MAX_SUPPLY uint64 21000000
+func parseConstant(value string, number int) (definitions.QConst, error) {
+	constComponents := strings.Split(value, " ")
+	if len(constComponents) != 3 {
+		return definitions.QConst{}, fmt.Errorf("parser error: Invalid constant %q at line %v: needs to be formatted as :const=NAME type value", value, number)
+	}
+	name, typ, val := constComponents[0], constComponents[1], constComponents[2]
+	if err := validateIdentifier(name, "constant"); err != nil {
+		return definitions.QConst{}, err
+	}
+	bits, err := strconv.Atoi(strings.TrimPrefix(strings.TrimPrefix(typ, "u"), "int"))
+	if !isValidBaseType(typ) || typ == "uniaddress" || err != nil {
+		return definitions.QConst{}, fmt.Errorf("parser error: Invalid constant type %v at line %v, valid types include: uint8-64 and int8-64", typ, number)
+	}
+	if strings.HasPrefix(typ, "u") {
+		parsed, err := strconv.ParseUint(val, 0, bits)
 		if err != nil {
-			return err
+			return definitions.QConst{}, fmt.Errorf("parser error: Invalid value %v for constant %v of type %v at line %v", val, name, typ, number)
 		}
-		validatedLocation, isURL, err := validateURL(location)
+		val = strconv.FormatUint(parsed, 10)
+	} else {
+		parsed, err := strconv.ParseInt(val, 0, bits)
 		if err != nil {
-			return err
+			return definitions.QConst{}, fmt.Errorf("parser error: Invalid value %v for constant %v of type %v at line %v", val, name, typ, number)
 		}
-		if !isURL {
-			currentDir, _ := os.Getwd()
-			err = os.Chdir(filepath.Dir(validatedLocation))
-			if err != nil {
-				return err
+		val = strconv.FormatInt(parsed, 10)
+	}
+	return definitions.QConst{Name: name, Type: typ, Value: val}, nil
+}
+
+// applyDoc attaches "##" doc comment lines to a function. Lines formatted as "@param name text"
+// document an input and "@return name text" document an output, everything else documents the function itself
+func applyDoc(daFunq *definitions.QFunc, docLines []string) error {
+	var funcDoc []string
+	for _, line := range docLines {
+		if !strings.HasPrefix(line, "@param ") && !strings.HasPrefix(line, "@return ") {
+			funcDoc = append(funcDoc, line)
+			continue
+		}
+		tag := strings.SplitN(line, " ", 3)
+		if len(tag) < 3 {
+			return fmt.Errorf("parser error: Invalid doc comment %q, needs to be formatted as %v name description", line, tag[0])
+		}
+		params := daFunq.Inputs
+		if tag[0] == "@return" {
+			params = daFunq.Outputs
+		}
+		found := false
+		for i := range params {
+			if params[i].TypeName == tag[1] {
+				params[i].Doc = strings.TrimSpace(tag[2])
+				found = true
+			}
+		}
+		if !found {
+			return fmt.Errorf("parser error: doc comment %v refers to unknown parameter %v of function %v", tag[0], tag[1], daFunq.FuncName)
+		}
+	}
+	daFunq.Doc = strings.Join(funcDoc, "\n")
+	return nil
+}
+
+// validateRoles ensures every only(role) modifier refers to a role declared for the contract
+func validateRoles(builtInterface definitions.QInterfaceBuilder) error {
+	declared := make(map[string]bool)
+	for _, role := range builtInterface.Roles {
+		declared[role] = true
+	}
+	for _, function := range builtInterface.Functions {
+		for _, role := range function.OnlyRoles {
+			if !declared[role] {
+				return fmt.Errorf("parser error: function %v requires undeclared role %v, declare it with :role=%v", function.FuncName, role, role)
 			}
-			defer os.Chdir(currentDir)
 		}
-		innerBuiltInterface, err := Parse(validatedLocation, isURL)
+	}
+	return nil
+}
+
+// implementInterface makes a block implement the interfaces listed in an :implements= attribute. Abstract
+// :interface= blocks are required, so the block has to declare their functions itself, while the functions of
+// contracts are pulled into the block. Interfaces are looked up among the blocks declared earlier in the same
+// file first, otherwise they are loaded from their location. Constants are always made visible to the block
+func (b *block) implementInterface(declared []definitions.QInterfaceBuilder, interfaceField string) error {
+	interfaceFilenames := splitTopLevel(interfaceField)
+	for _, interFilename := range interfaceFilenames {
+		interFilename, selection := splitSelection(strings.TrimSpace(interFilename))
+		innerBuiltInterface, err := findInterface(interFilename, declared)
+		if err != nil {
+			return err
+		}
+		innerBuiltInterface.Functions, err = selectFunctions(innerBuiltInterface, selection)
 		if err != nil {
 			return err
 		}
-		for _, val := range innerBuiltInterface.Functions {
-			if _, exists := qFuncSet[val.FuncName]; !exists {
-				qFuncSet[val.FuncName] = val
+		// an interface extending another interface simply inherits its functions
+		if innerBuiltInterface.IsInterface && !b.builtInterface.IsInterface {
+			b.required = append(b.required, innerBuiltInterface)
+		} else {
+			for _, val := range innerBuiltInterface.Functions {
+				if _, exists := b.qFuncSet[val.MangledName()]; !exists {
+					b.qFuncSet[val.MangledName()] = val
+					b.qFuncOrder = append(b.qFuncOrder, val.MangledName())
+				}
+			}
+		}
+		for _, constant := range innerBuiltInterface.Constants {
+			if err := addConstant(&b.builtInterface, constant); err != nil {
+				return err
 			}
 		}
 	}
 	return nil
 }
 
+// splitTopLevel splits a list of interfaces on the commas that are not inside () or {}
+func splitTopLevel(input string) []string {
+	var parts []string
+	var depth, start int
+	for i, c := range input {
+		switch c {
+		case '(', '{':
+			depth++
+		case ')', '}':
+			depth--
+		case ',':
+			if depth == 0 {
+				parts = append(parts, input[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(parts, input[start:])
+}
+
+// splitSelection splits an interface such as QRC20(./qrc20.abi){transfer,balanceOf} into the interface and its
+// selection of functions, the selection is empty when the interface is imported as a whole
+func splitSelection(interFilename string) (string, string) {
+	start := strings.Index(interFilename, "{")
+	if start == -1 {
+		return interFilename, ""
+	}
+	return strings.TrimSpace(interFilename[:start]), interFilename[start:]
+}
+
+// selectFunctions applies a selection such as {transfer as qrcTransfer, balanceOf} or {!approve} to the
+// functions of an interface. Listing functions imports only those, "!" excludes a function and
+// "name as alias" renames it. A selection made only of aliases and exclusions keeps every other function
+func selectFunctions(innerBuiltInterface definitions.QInterfaceBuilder, selection string) ([]definitions.QFunc, error) {
+	if selection == "" {
+		return innerBuiltInterface.Functions, nil
+	}
+	if !strings.HasSuffix(selection, "}") {
+		return nil, fmt.Errorf("parser error: Invalid selection %v of %v: should be formatted as {name, other as alias, !excluded}", selection, innerBuiltInterface.ContractName)
+	}
+	selected := make(map[string]bool)
+	excluded := make(map[string]bool)
+	aliases := make(map[string]string)
+	for _, item := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(selection, "{"), "}"), ",") {
+		words := strings.Fields(item)
+		var name string
+		switch {
+		case len(words) == 1 && strings.HasPrefix(words[0], "!"):
+			name = strings.TrimPrefix(words[0], "!")
+			excluded[name] = true
+		case len(words) == 1:
+			name = words[0]
+			selected[name] = true
+		case len(words) == 3 && words[1] == "as":
+			if err := validateIdentifier(words[2], "function"); err != nil {
+				return nil, err
+			}
+			name = words[0]
+			aliases[name] = words[2]
+		default:
+			return nil, fmt.Errorf("parser error: Invalid selection %q of %v: should be formatted as name, name as alias or !name", strings.TrimSpace(item), innerBuiltInterface.ContractName)
+		}
+		found := false
+		for _, function := range innerBuiltInterface.Functions {
+			found = found || function.FuncName == name
+		}
+		if !found {
+			return nil, fmt.Errorf("parser error: %v has no function %v to import", innerBuiltInterface.ContractName, name)
+		}
+	}
+	if len(selected) > 0 && len(excluded) > 0 {
+		return nil, fmt.Errorf("parser error: selection %v of %v can either list the functions to import or the functions to exclude, not both", selection, innerBuiltInterface.ContractName)
+	}
+
+	var functions []definitions.QFunc
+	for _, function := range innerBuiltInterface.Functions {
+		_, aliased := aliases[function.FuncName]
+		if excluded[function.FuncName] || (len(selected) > 0 && !selected[function.FuncName] && !aliased) {
+			continue
+		}
+		if aliased {
+			function.FuncName = aliases[function.FuncName]
+		}
+		functions = append(functions, function)
+	}
+	return functions, nil
+}
+
+func findInterface(interFilename string, declared []definitions.QInterfaceBuilder) (definitions.QInterfaceBuilder, error) {
+	name := strings.TrimSpace(strings.Split(interFilename, "(")[0])
+	if !strings.Contains(interFilename, "(") {
+		for _, local := range declared {
+			if local.ContractName == name {
+				return local, nil
+			}
+		}
+	}
+
+	location, err := getInterfaceLocation(interFilename)
+	if err != nil {
+		return definitions.QInterfaceBuilder{}, err
+	}
+	validatedLocation, isURL, err := validateURL(location)
+	if err != nil {
+		return definitions.QInterfaceBuilder{}, err
+	}
+	if !isURL {
+		currentDir, _ := os.Getwd()
+		err = os.Chdir(filepath.Dir(validatedLocation))
+		if err != nil {
+			return definitions.QInterfaceBuilder{}, err
+		}
+		defer os.Chdir(currentDir)
+	}
+	innerBuiltInterfaces, err := Parse(validatedLocation, isURL)
+	if err != nil {
+		return definitions.QInterfaceBuilder{}, err
+	}
+	// a file declaring several contracts or interfaces needs the right one picked by name
+	for _, inner := range innerBuiltInterfaces {
+		if inner.ContractName == name {
+			return inner, nil
+		}
+	}
+	if len(innerBuiltInterfaces) == 1 {
+		return innerBuiltInterfaces[0], nil
+	}
+	return definitions.QInterfaceBuilder{}, fmt.Errorf("parser error: %v declares %v contracts and interfaces, none of them named %v", location, len(innerBuiltInterfaces), name)
+}
+
 func getInterfaceLocation(abiFile string) (string, error) {
 	var startIndex int
 	var endIndex int
@@ -148,46 +610,135 @@ func validateURL(location string) (string, bool, error) {
 // the first output argument is a boolean to determine whether or not this is a name,
 // the second output argument is an  interface that should be either a string or a qFunc
 func parseLine(input string, number int) (component, interface{}, error) {
+	if strings.HasPrefix(input, "##") {
+		// is a doc comment for the function or storage slot that follows
+		doc := strings.TrimSpace(strings.TrimPrefix(input, "##"))
+		if breaker := commentBreaker(doc); breaker != "" {
+			return errorComponent, nil, fmt.Errorf("parser error: doc comment at line %v can't contain %v, which would end the comments of the generated code", number, breaker)
+		}
+		return docComponent, doc, nil
+	}
 	if strings.HasPrefix(input, "#") || input == "" {
 		// is a comment
 		return commentComponent, nil, nil
 	}
+	if input == ":storage" {
+		// starts a storage section, the slots follow on their own lines
+		return storageComponent, nil, nil
+	}
 	// split on white space first
 	firstGroup := strings.Split(input, " ")
-	if len(firstGroup) > 1 {
+	isAttribute := strings.HasPrefix(input, ":") && strings.Contains(firstGroup[0], "=")
+	if len(firstGroup) > 1 && !isAttribute {
 		// it's a function or a comment
 		daFunq, err := parseFunction(input, number)
 		return functionComponent, daFunq, err
 	}
-	// it's a interface attribute
-	isName, err := validateAttribute(firstGroup[0], number)
+	// it's a interface attribute, whose value may contain spaces
+	key, value, err := validateAttribute(input, number)
 	if err != nil {
 		return errorComponent, nil, err
-	} else if isName {
-		return nameComponent, strings.Split(input, "=")[1], nil
-	} else {
-		return interfaceComponent, strings.Split(input, "=")[1], nil
 	}
+	switch key {
+	case "name":
+		if err := validateIdentifier(value, "contract"); err != nil {
+			return errorComponent, nil, err
+		}
+		return nameComponent, value, nil
+	case "implements":
+		return interfaceComponent, value, nil
+	case "interface":
+		if err := validateIdentifier(value, "interface"); err != nil {
+			return errorComponent, nil, err
+		}
+		return abstractComponent, value, nil
+	case "role":
+		if !isValidIdentifier(value) {
+			return errorComponent, nil, fmt.Errorf("parser error: Invalid role name %q at line %v", value, number)
+		}
+		return roleComponent, value, nil
+	case "const":
+		constant, err := parseConstant(value, number)
+		if err != nil {
+			return errorComponent, nil, err
+		}
+		return constComponent, constant, nil
+	default:
+		if breaker := commentBreaker(value); breaker != "" {
+			return errorComponent, nil, fmt.Errorf("parser error: attribute %v at line %v can't contain %v, which would end the comments of the generated code", key, number, breaker)
+		}
+		return attributeComponent, attribute{key: key, value: value}, nil
+	}
+}
+
+// commentBreakers end the comments text is copied into early: */ the C, C++ and JSDoc comments, """ the Python
+// docstrings, and a backslash starts an escape sequence in the docstrings
+var commentBreakers = []string{"*/", `"""`, `\`}
+
+// commentBreaker returns the first of commentBreakers text contains, or an empty string when it can be copied
+// into the comments of the generated code as it is
+func commentBreaker(text string) string {
+	for _, breaker := range commentBreakers {
+		if strings.Contains(text, breaker) {
+			return breaker
+		}
+	}
+	return ""
+}
+
+// isStorageSlotLine reports whether a line inside a :storage section declares a slot;
+// any attribute or function line ends the section
+func isStorageSlotLine(input string) bool {
+	return input != "" && !strings.HasPrefix(input, ":") && !strings.HasPrefix(input, "#") && !strings.Contains(input, " ")
+}
+
+// parseStorageSlot parses a storage slot declaration such as balance:uint64
+func parseStorageSlot(input string, number int) (definitions.QType, error) {
+	slotComponents := strings.Split(input, ":")
+	if len(slotComponents) != 2 {
+		return definitions.QType{}, fmt.Errorf("parser error: Invalid storage slot %q at line %v: needs to be formatted as name:type", input, number)
+	}
+	if err := validateIdentifier(slotComponents[0], "storage slot"); err != nil {
+		return definitions.QType{}, err
+	}
+	if strings.HasPrefix(slotComponents[1], "map<") {
+		if !isValidMap(slotComponents[1]) {
+			return definitions.QType{}, fmt.Errorf("parser error: Invalid storage mapping %v at line %v: needs to be formatted as map<keytype,valuetype> with uint8-64, int8-64 or uniaddress keys and values", slotComponents[1], number)
+		}
+	} else if !isValidBaseType(slotComponents[1]) {
+		return definitions.QType{}, fmt.Errorf("parser error: Invalid storage type %v at line %v, valid types include: uint8-64, int8-64, uniaddress and map<keytype,valuetype>", slotComponents[1], number)
+	}
+	return definitions.QType{TypeName: slotComponents[0], Type: slotComponents[1]}, nil
 }
 
-func validateAttribute(input string, number int) (bool, error) {
+// validateAttribute splits an attribute line such as :name=MyContract into its key and value
+func validateAttribute(input string, number int) (string, string, error) {
 	// ensure that it's using proper syntax
-	secondGroup := strings.Split(input, ":")
-	if len(secondGroup) == 1 {
-		return false, fmt.Errorf("parser error: Expected \"%v\" at line %v", ":", number)
+	if !strings.Contains(input, ":") {
+		return "", "", fmt.Errorf("parser error: Expected \"%v\" at line %v", ":", number)
 	}
-	// ensure that it's using the "name" attribute, can add to this later but for now... technical debt!
-	finalGroup := strings.Split(secondGroup[1], "=")
-	if finalGroup[0] != "name" && finalGroup[0] != "implements" {
-		return false, fmt.Errorf("parser error: No such token \"%v\" available, try \"name\" or \"implements\" instead", finalGroup[0])
+	finalGroup := strings.SplitN(input[strings.Index(input, ":")+1:], "=", 2)
+	key := strings.Split(finalGroup[0], ":")[0]
+	if !isKnownAttribute(key) {
+		return "", "", fmt.Errorf("parser error: No such token \"%v\" available, try \"name\", \"interface\", \"implements\", \"role\", \"const\", \"%v\" or a custom \"x-\" attribute instead", key, strings.Join(metadataAttributes, "\", \""))
 	}
-	if len(finalGroup) != 2 {
-		return false, fmt.Errorf("parser error: Invalid formatting, \"name\" or \"implements\" should be in the following format: name=YourNameHere, implements=YourImplementationHere")
+	if len(finalGroup) != 2 || key != finalGroup[0] {
+		return "", "", fmt.Errorf("parser error: Invalid formatting, \"%v\" should be in the following format: %v=YourValueHere", key, key)
 	}
-	if finalGroup[0] == "name" {
-		return true, nil
+	return key, strings.TrimSpace(finalGroup[1]), nil
+}
+
+func isKnownAttribute(key string) bool {
+	switch key {
+	case "name", "interface", "implements", "role", "const":
+		return true
+	}
+	for _, metadata := range metadataAttributes {
+		if key == metadata {
+			return true
+		}
 	}
-	return false, nil
+	return strings.HasPrefix(key, "x-") && len(key) > 2
 }
 
 func parseFunction(input string, number int) (definitions.QFunc, error) {
@@ -195,6 +746,11 @@ func parseFunction(input string, number int) (definitions.QFunc, error) {
 	var outputs []definitions.QType
 	var name string
 
+	input, annotations, err := extractAnnotations(input)
+	if err != nil {
+		return definitions.QFunc{}, err
+	}
+
 	left, right, err := validateAndSplitFunc(input)
 	if err != nil {
 		return definitions.QFunc{}, err
@@ -205,8 +761,8 @@ func parseFunction(input string, number int) (definitions.QFunc, error) {
 		return definitions.QFunc{}, err
 	}
 
-	payable, err := validateMods(mods)
-	if err != nil {
+	daFunq := definitions.QFunc{FuncName: name}
+	if err := validateMods(&daFunq, mods); err != nil {
 		return definitions.QFunc{}, err
 	}
 
@@ -219,24 +775,57 @@ func parseFunction(input string, number int) (definitions.QFunc, error) {
 	if err != nil {
 		return definitions.QFunc{}, err
 	}
+	for _, output := range outputs {
+		if isValidContextType(output.Type) {
+			return definitions.QFunc{}, fmt.Errorf("parser error: context type %v of %v can only be used as an input", output.Type, output.TypeName)
+		}
+	}
 
-	return definitions.QFunc{FuncName: name, Inputs: inputs, Outputs: outputs, Payable: payable}, nil
-}
-
-// this is to be expanded in the future to encompass further modifiers
-func validateMods(mods []string) (bool, error) {
-	if len(mods) == 0 {
-		return false, nil
+	daFunq.Inputs = inputs
+	daFunq.Outputs = outputs
+	if err := validateSignatureNames(daFunq); err != nil {
+		return definitions.QFunc{}, err
 	}
-	// only doing payable now
-	if len(mods) > 1 {
-		return false, fmt.Errorf("parser error: more modifiers called than currently supported")
+	if err := applyAnnotations(&daFunq, annotations); err != nil {
+		return definitions.QFunc{}, err
 	}
-	if mods[0] == "payable" {
-		return true, nil
+	return daFunq, nil
+}
+
+// validateMods reads the modifiers following "fn", e.g. myFunc:fn:payable:only(owner,admin):nonreentrant
+// and records them on the function
+func validateMods(daFunq *definitions.QFunc, mods []string) error {
+	seen := make(map[string]bool)
+	for _, mod := range mods {
+		kind := mod
+		if strings.HasPrefix(mod, "only(") && strings.HasSuffix(mod, ")") {
+			kind = "only"
+		}
+		if seen[kind] {
+			if kind == "only" {
+				return fmt.Errorf("parser error: modifier only used more than once, list every allowed role in one only(...)")
+			}
+			return fmt.Errorf("parser error: modifier %v used more than once", kind)
+		}
+		seen[kind] = true
+
+		switch kind {
+		case "payable":
+			daFunq.Payable = true
+		case "nonreentrant":
+			daFunq.NonReentrant = true
+		case "only":
+			for _, role := range strings.Split(strings.TrimSuffix(strings.TrimPrefix(mod, "only("), ")"), ",") {
+				if !isValidIdentifier(role) {
+					return fmt.Errorf("parser error: Invalid role name %q in modifier %v", role, mod)
+				}
+				daFunq.OnlyRoles = append(daFunq.OnlyRoles, role)
+			}
+		default:
+			return fmt.Errorf("parser error: unknown modifier %q, valid modifiers are payable, only(role) and nonreentrant", mod)
+		}
 	}
-	// assume non payable
-	return false, nil
+	return nil
 }
 
 func getNameAndModsFromFunc(input string) (string, string, []string, error) {
@@ -283,7 +872,7 @@ func gatherTypes(input string) ([]definitions.QType, error) {
 				return nil, nil
 			}
 			return nil, fmt.Errorf("parser error: invalid type declaration %v", typeComponents[0])
-		} else if isValidArray(typeComponents[1]) || isValidBaseType(typeComponents[1]) {
+		} else if isValidArray(typeComponents[1]) || isValidFixedArray(typeComponents[1]) || isValidBaseType(typeComponents[1]) || isValidContextType(typeComponents[1]) {
 			maTypez = append(maTypez, definitions.QType{TypeName: typeComponents[0], Type: typeComponents[1]})
 		} else {
 			return nil, fmt.Errorf("parser error: Invalid type requested, valid types include: uint8-64, int8-64, fn and uniaddress: recieved %v", typeComponents[1])
@@ -310,6 +899,27 @@ func isValidArray(typ string) bool {
 	return false
 }
 
+// isValidFixedArray accepts integer arrays whose length is a number or a constant, e.g. uint8[32] or uint8[KEY_LEN]
+func isValidFixedArray(typ string) bool {
+	start := strings.Index(typ, "[")
+	if start == -1 || !strings.HasSuffix(typ, "]") || typ[:start] == "uniaddress" || !isValidBaseType(typ[:start]) {
+		return false
+	}
+	length := typ[start+1 : len(typ)-1]
+	if _, err := strconv.Atoi(length); err == nil {
+		return true
+	}
+	return isValidIdentifier(length)
+}
+
+func isValidMap(typ string) bool {
+	if !strings.HasPrefix(typ, "map<") || !strings.HasSuffix(typ, ">") {
+		return false
+	}
+	types := strings.Split(strings.TrimSuffix(strings.TrimPrefix(typ, "map<"), ">"), ",")
+	return len(types) == 2 && isValidBaseType(types[0]) && isValidBaseType(types[1])
+}
+
 func isValidBaseType(typ string) bool {
 	switch typ {
 	case "uint64", "uint32", "uint16", "uint8", "int64", "int32", "int16", "int8", "uniaddress":
@@ -318,3 +928,26 @@ func isValidBaseType(typ string) bool {
 		return false
 	}
 }
+
+// context types are filled in from the execution context by the dispatcher rather than passed by the caller
+func isValidContextType(typ string) bool {
+	switch typ {
+	case "@sender", "@value", "@origin":
+		return true
+	default:
+		return false
+	}
+}
+
+func isValidIdentifier(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, c := range name {
+		if c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (i > 0 && c >= '0' && c <= '9') {
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -1,6 +1,9 @@
 package parser
 
 import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"github.com/google/go-cmp/cmp"
@@ -57,8 +60,8 @@ func TestParseNameFailures(t *testing.T) {
 		output string
 	}{
 		{"name=AirDropToken", "parser error: Expected \":\" at line 0"},
-		{":version=0.2.0", "parser error: No such token \"version\" available, try \"name\" or \"implements\" instead"}, // todo: take this one out eventually
-		{":name:AirDropToken", "parser error: Invalid formatting, \"name\" or \"implements\" should be in the following format: name=YourNameHere, implements=YourImplementationHere"},
+		{":colour=red", "parser error: No such token \"colour\" available, try \"name\", \"interface\", \"implements\", \"role\", \"const\", \"version\", \"author\", \"license\", \"description\" or a custom \"x-\" attribute instead"},
+		{":name:AirDropToken", "parser error: Invalid formatting, \"name\" should be in the following format: name=YourValueHere"},
 	}
 
 	for _, test := range parserNameFailures {
@@ -90,6 +93,10 @@ func TestParseFunction(t *testing.T) {
 			"addressarray:uniaddress[] intarray:int64[] arrFunction:fn -> uintarray:uint32[]",
 			def.QFunc{FuncName: "arrFunction", Inputs: []def.QType{def.QType{TypeName: "addressarray", Type: "uniaddress[]"}, def.QType{TypeName: "intarray", Type: "int64[]"}}, Outputs: []def.QType{def.QType{TypeName: "uintarray", Type: "uint32[]"}}},
 		},
+		{
+			"key:uint8[KEY_LEN] fixedFunction:fn -> hash:uint8[32]",
+			def.QFunc{FuncName: "fixedFunction", Inputs: []def.QType{def.QType{TypeName: "key", Type: "uint8[KEY_LEN]"}}, Outputs: []def.QType{def.QType{TypeName: "hash", Type: "uint8[32]"}}},
+		},
 		{
 			"void voidFunction:fn -> a:uint32",
 			def.QFunc{FuncName: "voidFunction", Inputs: nil, Outputs: []def.QType{def.QType{TypeName: "a", Type: "uint32"}}},
@@ -98,6 +105,18 @@ func TestParseFunction(t *testing.T) {
 			"a:uint8 payableVoidFunc:fn:payable -> void",
 			def.QFunc{FuncName: "payableVoidFunc", Inputs: []def.QType{def.QType{TypeName: "a", Type: "uint8"}}, Outputs: nil, Payable: true},
 		},
+		{
+			"caller:@sender amt:@value a:uint8 contextFunc:fn -> void",
+			def.QFunc{FuncName: "contextFunc", Inputs: []def.QType{def.QType{TypeName: "caller", Type: "@sender"}, def.QType{TypeName: "amt", Type: "@value"}, def.QType{TypeName: "a", Type: "uint8"}}, Outputs: nil},
+		},
+		{
+			"a:uint8 ownerFunc:fn:payable:only(owner,admin) -> void",
+			def.QFunc{FuncName: "ownerFunc", Inputs: []def.QType{def.QType{TypeName: "a", Type: "uint8"}}, Outputs: nil, Payable: true, OnlyRoles: []string{"owner", "admin"}},
+		},
+		{
+			"void lockedFunc:fn:nonreentrant -> a:uint8",
+			def.QFunc{FuncName: "lockedFunc", Inputs: nil, Outputs: []def.QType{def.QType{TypeName: "a", Type: "uint8"}}, NonReentrant: true},
+		},
 	}
 
 	for _, test := range functionInputs {
@@ -133,6 +152,22 @@ func TestParseFunctionErrors(t *testing.T) {
 			"somevar:uint32 -> otherFunction:fn -> somereturn:uin32",
 			"parser error: unexpected multiple \"->\"s in function signature",
 		},
+		{
+			"void contextFunc:fn -> caller:@sender",
+			"parser error: context type @sender of caller can only be used as an input",
+		},
+		{
+			"a:uint8 otherFunction:fn:payabel -> void",
+			"parser error: unknown modifier \"payabel\", valid modifiers are payable, only(role) and nonreentrant",
+		},
+		{
+			"a:uint8 otherFunction:fn:nonreentrant:nonreentrant -> void",
+			"parser error: modifier nonreentrant used more than once",
+		},
+		{
+			"a:uint8 otherFunction:fn:only(owner):only(admin) -> void",
+			"parser error: modifier only used more than once, list every allowed role in one only(...)",
+		},
 	}
 
 	for _, test := range functionInputs {
@@ -146,3 +181,456 @@ func TestParseFunctionErrors(t *testing.T) {
 		}
 	}
 }
+
+func TestParseMetadataAttribute(t *testing.T) {
+	var attributeInputs = []struct {
+		input  string
+		output attribute
+	}{
+		{":version=0.2.0", attribute{key: "version", value: "0.2.0"}},
+		{":license=MIT", attribute{key: "license", value: "MIT"}},
+		{":description=A token contract: with spaces = and more", attribute{key: "description", value: "A token contract: with spaces = and more"}},
+		{":x-audited-by=Some Firm", attribute{key: "x-audited-by", value: "Some Firm"}},
+	}
+
+	for _, test := range attributeInputs {
+		component, attr, err := parseLine(test.input, 0)
+		if err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+		if component != attributeComponent || attr.(attribute) != test.output {
+			t.Errorf("Expected attribute %v, got %v", test.output, attr)
+		}
+	}
+
+	wantErr := "parser error: attribute description at line 2 can't contain */, which would end the comments of the generated code"
+	if _, _, err := parseLine(":description=Holds coins */ int x;", 2); err == nil || err.Error() != wantErr {
+		t.Errorf("Expected error %v, got %v", wantErr, err)
+	}
+}
+
+func TestParseRole(t *testing.T) {
+	component, role, err := parseLine(":role=owner", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if component != roleComponent || role.(string) != "owner" {
+		t.Errorf("Expected role owner, got %v", role)
+	}
+
+	if _, _, err := parseLine(":role=own-er", 0); err == nil {
+		t.Errorf("Expected an invalid role name to be rejected")
+	}
+}
+
+func TestValidateRoles(t *testing.T) {
+	builder := def.QInterfaceBuilder{
+		ContractName: "MyContract",
+		Roles:        []string{"owner"},
+		Functions: []def.QFunc{
+			def.QFunc{FuncName: "mint", OnlyRoles: []string{"owner"}},
+		},
+	}
+	if err := validateRoles(builder); err != nil {
+		t.Errorf("Expected no error, got %v", err)
+	}
+
+	builder.Functions = append(builder.Functions, def.QFunc{FuncName: "pause", OnlyRoles: []string{"admin"}})
+	want := "parser error: function pause requires undeclared role admin, declare it with :role=admin"
+	if err := validateRoles(builder); err == nil || err.Error() != want {
+		t.Errorf("Expected error %v, got %v", want, err)
+	}
+}
+
+func TestParseStorageSlot(t *testing.T) {
+	if component, _, err := parseLine(":storage", 0); err != nil || component != storageComponent {
+		t.Fatalf("Expected a storage section, got component %v and error %v", component, err)
+	}
+
+	slot, err := parseStorageSlot("owner:uniaddress", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if !cmp.Equal(slot, def.QType{TypeName: "owner", Type: "uniaddress"}) {
+		t.Errorf("Expected slot owner:uniaddress, got %v", slot)
+	}
+
+	slot, err = parseStorageSlot("balances:map<uniaddress,uint64>", 1)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if !cmp.Equal(slot, def.QType{TypeName: "balances", Type: "map<uniaddress,uint64>"}) {
+		t.Errorf("Expected slot balances:map<uniaddress,uint64>, got %v", slot)
+	}
+
+	var storageFailures = []struct {
+		input  string
+		output string
+	}{
+		{"balance", "parser error: Invalid storage slot \"balance\" at line 1: needs to be formatted as name:type"},
+		{"balance:uint64:uint8", "parser error: Invalid storage slot \"balance:uint64:uint8\" at line 1: needs to be formatted as name:type"},
+		{"balances:uint64[]", "parser error: Invalid storage type uint64[] at line 1, valid types include: uint8-64, int8-64, uniaddress and map<keytype,valuetype>"},
+		{"balances:map<uniaddress>", "parser error: Invalid storage mapping map<uniaddress> at line 1: needs to be formatted as map<keytype,valuetype> with uint8-64, int8-64 or uniaddress keys and values"},
+		{"balances:map<uniaddress,uint64[]>", "parser error: Invalid storage mapping map<uniaddress,uint64[]> at line 1: needs to be formatted as map<keytype,valuetype> with uint8-64, int8-64 or uniaddress keys and values"},
+	}
+	for _, test := range storageFailures {
+		_, err := parseStorageSlot(test.input, 1)
+		if err == nil || err.Error() != test.output {
+			t.Errorf("Expected error %v, got %v", test.output, err)
+		}
+	}
+}
+
+func TestParseDocComment(t *testing.T) {
+	component, doc, err := parseLine("## Adds coins to an account.", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if component != docComponent || doc.(string) != "Adds coins to an account." {
+		t.Errorf("Expected doc comment %q, got %v", "Adds coins to an account.", doc)
+	}
+
+	daFunq := def.QFunc{
+		FuncName: "addCoins",
+		Inputs:   []def.QType{def.QType{TypeName: "numCoins", Type: "uint64"}},
+		Outputs:  []def.QType{def.QType{TypeName: "sumCoins", Type: "uint64"}},
+	}
+	docLines := []string{"Adds coins to an account.", "@param numCoins number of coins to add", "@return sumCoins the new balance"}
+	if err := applyDoc(&daFunq, docLines); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	want := def.QFunc{
+		FuncName: "addCoins",
+		Inputs:   []def.QType{def.QType{TypeName: "numCoins", Type: "uint64", Doc: "number of coins to add"}},
+		Outputs:  []def.QType{def.QType{TypeName: "sumCoins", Type: "uint64", Doc: "the new balance"}},
+		Doc:      "Adds coins to an account.",
+	}
+	if !cmp.Equal(daFunq, want) {
+		t.Errorf("Expected output to equal %v: got output %v", want, daFunq)
+	}
+
+	wantErr := "parser error: doc comment @param refers to unknown parameter coins of function addCoins"
+	if err := applyDoc(&daFunq, []string{"@param coins number of coins"}); err == nil || err.Error() != wantErr {
+		t.Errorf("Expected error %v, got %v", wantErr, err)
+	}
+
+	for input, breaker := range map[string]string{
+		"## ends the comment */ early":            "*/",
+		`## @param numCoins a """quoted""" count`: `"""`,
+		`## stored under C:\coins`:                `\`,
+	} {
+		wantErr := "parser error: doc comment at line 3 can't contain " + breaker + ", which would end the comments of the generated code"
+		if _, _, err := parseLine(input, 3); err == nil || err.Error() != wantErr {
+			t.Errorf("Expected error %v parsing %v, got %v", wantErr, input, err)
+		}
+	}
+}
+
+func TestParseConstant(t *testing.T) {
+	component, constant, err := parseLine(":const=MAX_SUPPLY uint64 21000000", 0)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	want := def.QConst{Name: "MAX_SUPPLY", Type: "uint64", Value: "21000000"}
+	if component != constComponent || constant.(def.QConst) != want {
+		t.Errorf("Expected constant %v, got %v", want, constant)
+	}
+
+	if _, constant, _ := parseLine(":const=KEY_LEN uint8 0x20", 0); constant.(def.QConst).Value != "32" {
+		t.Errorf("Expected hexadecimal constant to be normalized to 32, got %v", constant)
+	}
+
+	var constantFailures = []struct {
+		input  string
+		output string
+	}{
+		{":const=MAX_SUPPLY uint64", "parser error: Invalid constant \"MAX_SUPPLY uint64\" at line 0: needs to be formatted as :const=NAME type value"},
+		{":const=MAX-SUPPLY uint64 1", "parser error: Invalid constant name \"MAX-SUPPLY\", names may only contain letters, digits and underscores and can't start with a digit"},
+		{":const=OWNER uniaddress 1", "parser error: Invalid constant type uniaddress at line 0, valid types include: uint8-64 and int8-64"},
+		{":const=SMALL uint8 256", "parser error: Invalid value 256 for constant SMALL of type uint8 at line 0"},
+		{":const=NEGATIVE uint8 -1", "parser error: Invalid value -1 for constant NEGATIVE of type uint8 at line 0"},
+	}
+	for _, test := range constantFailures {
+		_, _, err := parseLine(test.input, 0)
+		if err == nil || err.Error() != test.output {
+			t.Errorf("Expected error %v, got %v", test.output, err)
+		}
+	}
+}
+
+func TestResolveArrayLengths(t *testing.T) {
+	builder := def.QInterfaceBuilder{
+		ContractName: "MyContract",
+		Constants:    []def.QConst{def.QConst{Name: "KEY_LEN", Type: "uint8", Value: "32"}},
+		Functions: []def.QFunc{
+			def.QFunc{
+				FuncName: "fixedFunction",
+				Inputs:   []def.QType{def.QType{TypeName: "key", Type: "uint8[KEY_LEN]"}},
+				Outputs:  []def.QType{def.QType{TypeName: "hash", Type: "uint8[20]"}},
+			},
+		},
+	}
+	if err := resolveArrayLengths(&builder); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if builder.Functions[0].Inputs[0].Length != 32 || builder.Functions[0].Outputs[0].Length != 20 {
+		t.Errorf("Expected lengths 32 and 20, got %v", builder.Functions[0])
+	}
+
+	builder.Functions[0].Inputs[0].Type = "uint8[SIG_LEN]"
+	want := "parser error: array length SIG_LEN of key in function fixedFunction is neither a number nor a declared constant"
+	if err := resolveArrayLengths(&builder); err == nil || err.Error() != want {
+		t.Errorf("Expected error %v, got %v", want, err)
+	}
+}
+
+func TestParseImplementsConstants(t *testing.T) {
+	dir, err := ioutil.TempDir("", "simpleabi")
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"Keys.abi":     ":name=Keys\n:const=KEY_LEN uint8 32\n",
+		"Contract.abi": ":name=Contract\n:implements=Keys(./Keys.abi)\nkey:uint8[KEY_LEN] store:fn -> void\n",
+	}
+	for name, contents := range files {
+		if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0666); err != nil {
+			t.Fatalf("Unexpected error occurred: %v", err)
+		}
+	}
+	currentDir, _ := os.Getwd()
+	defer os.Chdir(currentDir)
+	os.Chdir(dir)
+
+	builders, err := Parse("Contract.abi", false)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	builder := builders[0]
+	if len(builder.Constants) != 1 || builder.Constants[0].Name != "KEY_LEN" {
+		t.Errorf("Expected constant KEY_LEN from the implemented interface, got %v", builder.Constants)
+	}
+	if builder.Functions[0].Inputs[0].Length != 32 {
+		t.Errorf("Expected key to resolve to length 32, got %v", builder.Functions[0].Inputs[0])
+	}
+}
+
+func TestParseMultipleBlocks(t *testing.T) {
+	dir, err := ioutil.TempDir("", "simpleabi")
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	const contents = `:interface=Ownable
+void owner:fn -> o:uniaddress
+
+:name=Token
+:implements=Ownable
+a:uint64 mint:fn -> void
+void owner:fn -> o:uniaddress
+
+:name=Vault
+void withdraw:fn -> void
+`
+	location := filepath.Join(dir, "Multi.abi")
+	if err := ioutil.WriteFile(location, []byte(contents), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+
+	builders, err := Parse(location, false)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if len(builders) != 3 {
+		t.Fatalf("Expected 3 blocks, got %v", builders)
+	}
+	for i, want := range []struct {
+		name        string
+		isInterface bool
+		functions   []string
+	}{
+		{"Ownable", true, []string{"owner"}},
+		{"Token", false, []string{"mint", "owner"}},
+		{"Vault", false, []string{"withdraw"}},
+	} {
+		if builders[i].ContractName != want.name || builders[i].IsInterface != want.isInterface {
+			t.Errorf("Expected block %v to be %v (interface %v), got %v (interface %v)", i, want.name, want.isInterface, builders[i].ContractName, builders[i].IsInterface)
+		}
+		var functions []string
+		for _, function := range builders[i].Functions {
+			functions = append(functions, function.FuncName)
+		}
+		if !cmp.Equal(functions, want.functions) {
+			t.Errorf("Expected %v to have functions %v, got %v", want.name, want.functions, functions)
+		}
+	}
+
+	if !cmp.Equal(builders[1].Implements, []string{"Ownable"}) {
+		t.Errorf("Expected Token to record that it implements Ownable, got %v", builders[1].Implements)
+	}
+
+	duplicate := filepath.Join(dir, "Duplicate.abi")
+	if err := ioutil.WriteFile(duplicate, []byte(":name=Token\n:name=Token\n"), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	want := "parser error: Token declared more than once at line 1"
+	if _, err := Parse(duplicate, false); err == nil || err.Error() != want {
+		t.Errorf("Expected error %v, got %v", want, err)
+	}
+}
+
+func TestCheckConformance(t *testing.T) {
+	ownable := def.QInterfaceBuilder{
+		ContractName: "Ownable",
+		IsInterface:  true,
+		Functions: []def.QFunc{
+			def.QFunc{FuncName: "transferOwnership", Inputs: []def.QType{def.QType{TypeName: "newOwner", Type: "uniaddress"}}},
+		},
+	}
+	var conformanceTests = []struct {
+		function def.QFunc
+		err      string
+	}{
+		{
+			// parameter names, context parameters and implementation modifiers may differ
+			def.QFunc{FuncName: "transferOwnership", Inputs: []def.QType{def.QType{TypeName: "caller", Type: "@sender"}, def.QType{TypeName: "to", Type: "uniaddress"}}, OnlyRoles: []string{"owner"}},
+			"",
+		},
+		{
+			def.QFunc{FuncName: "renounceOwnership"},
+			"parser error: Token does not implement Ownable: missing function \"uniaddress transferOwnership:fn -> void\"",
+		},
+		{
+			def.QFunc{FuncName: "transferOwnership", Inputs: []def.QType{def.QType{TypeName: "newOwner", Type: "uint64"}}},
+			"parser error: Token does not implement Ownable: function transferOwnership is declared as \"uint64 transferOwnership:fn -> void\" but Ownable requires \"uniaddress transferOwnership:fn -> void\"",
+		},
+		{
+			def.QFunc{FuncName: "transferOwnership", Inputs: []def.QType{def.QType{TypeName: "newOwner", Type: "uniaddress"}}, Payable: true},
+			"parser error: Token does not implement Ownable: function transferOwnership is declared as \"uniaddress transferOwnership:fn:payable -> void\" but Ownable requires \"uniaddress transferOwnership:fn -> void\"",
+		},
+	}
+
+	for _, test := range conformanceTests {
+		token := def.QInterfaceBuilder{ContractName: "Token", Functions: []def.QFunc{test.function}}
+		err := checkConformance(token, ownable)
+		if test.err == "" && err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		} else if test.err != "" && (err == nil || err.Error() != test.err) {
+			t.Errorf("Expected error %v, got %v", test.err, err)
+		}
+	}
+}
+
+func TestSelectFunctions(t *testing.T) {
+	qrc20 := def.QInterfaceBuilder{
+		ContractName: "QRC20",
+		Functions: []def.QFunc{
+			def.QFunc{FuncName: "transfer"},
+			def.QFunc{FuncName: "balanceOf"},
+			def.QFunc{FuncName: "approve"},
+		},
+	}
+	var selectionTests = []struct {
+		selection string
+		functions []string
+		err       string
+	}{
+		{"", []string{"transfer", "balanceOf", "approve"}, ""},
+		{"{transfer,balanceOf}", []string{"transfer", "balanceOf"}, ""},
+		{"{!approve}", []string{"transfer", "balanceOf"}, ""},
+		{"{transfer as qrcTransfer}", []string{"qrcTransfer", "balanceOf", "approve"}, ""},
+		{"{transfer as qrcTransfer, balanceOf}", []string{"qrcTransfer", "balanceOf"}, ""},
+		{"{transfer as qrcTransfer, !approve}", []string{"qrcTransfer", "balanceOf"}, ""},
+		{"{mint}", nil, "parser error: QRC20 has no function mint to import"},
+		{"{transfer, !approve}", nil, "parser error: selection {transfer, !approve} of QRC20 can either list the functions to import or the functions to exclude, not both"},
+		{"{transfer to qrcTransfer}", nil, "parser error: Invalid selection \"transfer to qrcTransfer\" of QRC20: should be formatted as name, name as alias or !name"},
+	}
+
+	for _, test := range selectionTests {
+		functions, err := selectFunctions(qrc20, test.selection)
+		if test.err != "" {
+			if err == nil || err.Error() != test.err {
+				t.Errorf("Expected error %v, got %v", test.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("Expected no error, got %v", err)
+		}
+		var names []string
+		for _, function := range functions {
+			names = append(names, function.FuncName)
+		}
+		if !cmp.Equal(names, test.functions) {
+			t.Errorf("Expected selection %v to import %v, got %v", test.selection, test.functions, names)
+		}
+	}
+}
+
+func TestParseImplementsSelection(t *testing.T) {
+	dir, err := ioutil.TempDir("", "simpleabi")
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	const contents = `:name=QRC20
+to:uniaddress value:uint64 transfer:fn -> void
+owner:uniaddress balanceOf:fn -> balance:uint64
+
+:name=QRC721
+to:uniaddress id:uint64 transfer:fn -> void
+
+:name=Bridge
+:implements=QRC20{transfer as qrcTransfer, balanceOf}, QRC721{transfer as nftTransfer}
+`
+	location := filepath.Join(dir, "Bridge.abi")
+	if err := ioutil.WriteFile(location, []byte(contents), 0666); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+
+	builders, err := Parse(location, false)
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	functions := make(map[string]bool)
+	for _, function := range builders[2].Functions {
+		functions[function.FuncName] = true
+	}
+	if !cmp.Equal(functions, map[string]bool{"qrcTransfer": true, "balanceOf": true, "nftTransfer": true}) {
+		t.Errorf("Expected Bridge to import qrcTransfer, balanceOf and nftTransfer, got %v", functions)
+	}
+}
+
+func TestMarkOverloads(t *testing.T) {
+	builder := def.QInterfaceBuilder{
+		ContractName: "Token",
+		Functions: []def.QFunc{
+			def.QFunc{FuncName: "transfer", Inputs: []def.QType{def.QType{TypeName: "to", Type: "uniaddress"}, def.QType{TypeName: "amount", Type: "uint64"}}},
+			def.QFunc{FuncName: "transfer", Inputs: []def.QType{def.QType{TypeName: "to", Type: "uniaddress"}, def.QType{TypeName: "amount", Type: "uint64"}, def.QType{TypeName: "data", Type: "uint8[]"}}},
+			def.QFunc{FuncName: "balanceOf", Inputs: []def.QType{def.QType{TypeName: "owner", Type: "uniaddress"}}},
+		},
+	}
+	if err := markOverloads(&builder); err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	var names []string
+	for _, function := range builder.Functions {
+		names = append(names, function.CName())
+	}
+	want := []string{"transfer__addr_u64", "transfer__addr_u64_u8arr", "balanceOf"}
+	if !cmp.Equal(names, want) {
+		t.Errorf("Expected C names %v, got %v", want, names)
+	}
+
+	// overloads that only differ in their outputs or parameter names can't be told apart
+	builder.Functions = append(builder.Functions, def.QFunc{
+		FuncName: "transfer",
+		Inputs:   []def.QType{def.QType{TypeName: "recipient", Type: "uniaddress"}, def.QType{TypeName: "value", Type: "uint64"}},
+		Outputs:  []def.QType{def.QType{TypeName: "ok", Type: "uint8"}},
+	})
+	wantErr := "parser error: function transfer of Token is declared more than once with the same input types, overloads have to differ in their inputs"
+	if err := markOverloads(&builder); err == nil || err.Error() != wantErr {
+		t.Errorf("Expected error %v, got %v", wantErr, err)
+	}
+}
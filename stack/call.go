@@ -0,0 +1,29 @@
+package stack
+
+// Function describes a function of a contract, generated bindings list them in their Functions variable
+type Function struct {
+	// Name is the name of the function in the .abi file
+	Name string
+	// Signature is the canonical signature of the function, e.g. "uniaddress uint64 transfer:fn:payable -> uint8"
+	Signature string
+	// Selector is the function ID pushed on top of the inputs
+	Selector uint32
+	// Payable tells whether the function accepts coins sent along with the call
+	Payable bool
+	// Gas is the gas limit of the function's @gas annotation, or 0 when it has none
+	Gas uint64
+}
+
+// CallOptions are the options of a single call
+type CallOptions struct {
+	// Value is the amount of coins sent along with the call, only payable functions accept any
+	Value uint64
+	// GasLimit is the gas limit of the call, 0 leaves it to the Caller
+	GasLimit uint64
+}
+
+// Caller sends call data to a deployed contract and returns the data the contract returned,
+// e.g. through the RPC interface of a Qtum node. Implementations know the address of the contract
+type Caller interface {
+	Call(data []byte, options CallOptions) ([]byte, error)
+}
@@ -0,0 +1,205 @@
+// Package stack encodes and decodes the call stack Qtum x86 contracts receive their inputs on and push their outputs onto.
+// It is the runtime of the Go bindings simpleabi generates with --lang go, and can be used to build call data by hand.
+//
+// A serialized stack lists its items from the bottom to the top, every item being its bytes followed by its
+// length as a little endian uint32, so the top item can be found from the end of the data. Integers are
+// little endian, arrays are their elements back to back and addresses are UniversalAddressABI structs.
+//
+// The generated C dispatcher pops the function ID first and the inputs in the order they are declared,
+// so call data ends with the ID on top of the first input. The outputs are pushed in the order they are
+// declared, leaving the last output on top.
+package stack
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"reflect"
+)
+
+// AddressSize is the size of an Address on the stack
+const AddressSize = 36
+
+// Address is a UniversalAddressABI: the address version followed by 32 bytes of address data
+type Address struct {
+	Version uint32
+	Data    [32]byte
+}
+
+// ErrEmpty is returned when popping an item off an empty stack
+var ErrEmpty = errors.New("stack: pop from an empty stack")
+
+// Stack is a call stack, its zero value is an empty stack ready to use
+type Stack struct {
+	items [][]byte
+}
+
+// Decode parses call data or the data returned by a call
+func Decode(data []byte) (*Stack, error) {
+	var items [][]byte
+	for end := len(data); end > 0; {
+		if end < 4 {
+			return nil, fmt.Errorf("stack: %v bytes left before the first item, expected a length", end)
+		}
+		size := int(binary.LittleEndian.Uint32(data[end-4 : end]))
+		if size > end-4 {
+			return nil, fmt.Errorf("stack: item of %v bytes does not fit in the %v bytes before it", size, end-4)
+		}
+		items = append([][]byte{data[end-4-size : end-4]}, items...)
+		end -= 4 + size
+	}
+	return &Stack{items: items}, nil
+}
+
+// Encode serializes the stack, see the package documentation for the format
+func (s *Stack) Encode() []byte {
+	var buf bytes.Buffer
+	for _, item := range s.items {
+		buf.Write(item)
+		binary.Write(&buf, binary.LittleEndian, uint32(len(item)))
+	}
+	return buf.Bytes()
+}
+
+// Len returns the number of items on the stack
+func (s *Stack) Len() int {
+	return len(s.items)
+}
+
+// Push pushes an item onto the top of the stack
+func (s *Stack) Push(item []byte) {
+	s.items = append(s.items, item)
+}
+
+// Pop pops the item at the top of the stack
+func (s *Stack) Pop() ([]byte, error) {
+	if len(s.items) == 0 {
+		return nil, ErrEmpty
+	}
+	item := s.items[len(s.items)-1]
+	s.items = s.items[:len(s.items)-1]
+	return item, nil
+}
+
+// PushInteger pushes a uint8 to int64, as qtumPush8 to qtumPush64 do. It panics on any other type
+func PushInteger(s *Stack, value interface{}) {
+	mustBe(reflect.TypeOf(value), isInteger, "an integer")
+	s.Push(encode(value))
+}
+
+// PopInteger pops an integer into value, a pointer to a uint8 to int64. The item has to be exactly as big as the integer
+func PopInteger(s *Stack, value interface{}) error {
+	if err := checkPointer(value, reflect.Invalid, isInteger, "an integer"); err != nil {
+		return err
+	}
+	return pop(s, value, binary.Size(value))
+}
+
+// PushAddress pushes an address
+func PushAddress(s *Stack, address Address) {
+	s.Push(encode(address))
+}
+
+// PopAddress pops an address, the item has to be exactly AddressSize bytes
+func PopAddress(s *Stack) (Address, error) {
+	var address Address
+	err := pop(s, &address, AddressSize)
+	return address, err
+}
+
+// PushArray pushes the elements of a slice or array of integers or Addresses as a single item. It panics on any other type
+func PushArray(s *Stack, values interface{}) {
+	typ := reflect.TypeOf(values)
+	if typ == nil || typ.Kind() != reflect.Slice && typ.Kind() != reflect.Array {
+		panic(fmt.Sprintf("stack: %T is not an array", values))
+	}
+	mustBe(typ.Elem(), isElement, "an integer or Address")
+	s.Push(encode(values))
+}
+
+// PopArray pops a dynamic array into values, a pointer to a slice of integers or Addresses. The item has to be
+// a whole number of elements
+func PopArray(s *Stack, values interface{}) error {
+	if err := checkPointer(values, reflect.Slice, isElement, "a slice of integers or Addresses"); err != nil {
+		return err
+	}
+	slice := reflect.ValueOf(values).Elem()
+	size := int(slice.Type().Elem().Size())
+	item, err := s.Pop()
+	if err != nil {
+		return err
+	}
+	if len(item)%size != 0 {
+		return fmt.Errorf("stack: item of %v bytes is not an array of %v byte elements", len(item), size)
+	}
+	slice.Set(reflect.MakeSlice(slice.Type(), len(item)/size, len(item)/size))
+	return binary.Read(bytes.NewReader(item), binary.LittleEndian, slice.Interface())
+}
+
+// PopFixedArray pops a fixed size array into values, a pointer to an array of integers or Addresses. The item has
+// to be exactly as big as the array
+func PopFixedArray(s *Stack, values interface{}) error {
+	if err := checkPointer(values, reflect.Array, isElement, "an array of integers or Addresses"); err != nil {
+		return err
+	}
+	return pop(s, values, binary.Size(values))
+}
+
+func isInteger(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return true
+	default:
+		return false
+	}
+}
+
+// isElement reports whether arrays on the stack can be made of typ
+func isElement(typ reflect.Type) bool {
+	return isInteger(typ) || typ == reflect.TypeOf(Address{})
+}
+
+func mustBe(typ reflect.Type, is func(reflect.Type) bool, what string) {
+	if typ == nil || !is(typ) {
+		panic(fmt.Sprintf("stack: %v is not %v", typ, what))
+	}
+}
+
+// checkPointer checks that value points to a value of kind whose elements, or itself for reflect.Invalid, pass is
+func checkPointer(value interface{}, kind reflect.Kind, is func(reflect.Type) bool, what string) error {
+	typ := reflect.TypeOf(value)
+	if typ == nil || typ.Kind() != reflect.Ptr || reflect.ValueOf(value).IsNil() {
+		return fmt.Errorf("stack: %T is not a pointer to %v", value, what)
+	}
+	typ = typ.Elem()
+	if kind != reflect.Invalid {
+		if typ.Kind() != kind {
+			return fmt.Errorf("stack: %T is not a pointer to %v", value, what)
+		}
+		typ = typ.Elem()
+	}
+	if !is(typ) {
+		return fmt.Errorf("stack: %T is not a pointer to %v", value, what)
+	}
+	return nil
+}
+
+func encode(value interface{}) []byte {
+	var buf bytes.Buffer
+	// writing fixed size values to a bytes.Buffer can't fail
+	binary.Write(&buf, binary.LittleEndian, value)
+	return buf.Bytes()
+}
+
+// pop pops an item of exactly size bytes into value
+func pop(s *Stack, value interface{}, size int) error {
+	item, err := s.Pop()
+	if err != nil {
+		return err
+	}
+	if len(item) != size {
+		return fmt.Errorf("stack: item of %v bytes, expected %v", len(item), size)
+	}
+	return binary.Read(bytes.NewReader(item), binary.LittleEndian, value)
+}
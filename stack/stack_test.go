@@ -0,0 +1,140 @@
+package stack
+
+import (
+	"bytes"
+	"reflect"
+	"testing"
+)
+
+func TestEncode(t *testing.T) {
+	var s Stack
+	PushInteger(&s, uint16(0x0102))
+	PushArray(&s, []uint8{7, 8, 9})
+	PushInteger(&s, uint32(0xdeadbeef))
+	want := []byte{
+		0x02, 0x01, 2, 0, 0, 0,
+		7, 8, 9, 3, 0, 0, 0,
+		0xef, 0xbe, 0xad, 0xde, 4, 0, 0, 0,
+	}
+	if got := s.Encode(); !bytes.Equal(got, want) {
+		t.Errorf("Unexpected encoding, got %v, want %v", got, want)
+	}
+}
+
+func TestRoundTrip(t *testing.T) {
+	address := Address{Version: 1, Data: [32]byte{0xaa, 0xbb}}
+	var s Stack
+	PushAddress(&s, address)
+	PushArray(&s, []Address{address, {}})
+	PushArray(&s, []int16{-1, 2})
+	PushArray(&s, []uint32{})
+	PushInteger(&s, int64(-5))
+
+	decoded, err := Decode(s.Encode())
+	if err != nil {
+		t.Fatalf("Unexpected error occurred: %v", err)
+	}
+	if decoded.Len() != 5 {
+		t.Fatalf("Expected 5 items, got %v", decoded.Len())
+	}
+	var integer int64
+	if err := PopInteger(decoded, &integer); err != nil || integer != -5 {
+		t.Errorf("Expected -5, got %v, %v", integer, err)
+	}
+	var empty []uint32
+	if err := PopArray(decoded, &empty); err != nil || len(empty) != 0 {
+		t.Errorf("Expected an empty array, got %v, %v", empty, err)
+	}
+	var fixed [2]int16
+	if err := PopFixedArray(decoded, &fixed); err != nil || fixed != [2]int16{-1, 2} {
+		t.Errorf("Expected [-1 2], got %v, %v", fixed, err)
+	}
+	var addresses []Address
+	if err := PopArray(decoded, &addresses); err != nil || !reflect.DeepEqual(addresses, []Address{address, {}}) {
+		t.Errorf("Expected two addresses, got %v, %v", addresses, err)
+	}
+	if v, err := PopAddress(decoded); err != nil || v != address {
+		t.Errorf("Expected %v, got %v, %v", address, v, err)
+	}
+	if _, err := decoded.Pop(); err != ErrEmpty {
+		t.Errorf("Expected ErrEmpty, got %v", err)
+	}
+}
+
+func TestPopErrors(t *testing.T) {
+	var s Stack
+	PushInteger(&s, uint16(1))
+	var integer uint32
+	if err := PopInteger(&s, &integer); err == nil || err.Error() != "stack: item of 2 bytes, expected 4" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	PushArray(&s, []uint8{1, 2, 3})
+	var array []uint16
+	if err := PopArray(&s, &array); err == nil || err.Error() != "stack: item of 3 bytes is not an array of 2 byte elements" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+	PushArray(&s, []uint8{1, 2, 3})
+	var fixed [4]uint8
+	if err := PopFixedArray(&s, &fixed); err == nil || err.Error() != "stack: item of 3 bytes, expected 4" {
+		t.Errorf("Unexpected error: %v", err)
+	}
+}
+
+func TestTypeErrors(t *testing.T) {
+	var s Stack
+	PushInteger(&s, uint8(1))
+	var notPointer uint8
+	var integer int
+	var slice []string
+	var array [2]uint8
+	tests := []struct {
+		pop func() error
+		err string
+	}{
+		{func() error { return PopInteger(&s, notPointer) }, "stack: uint8 is not a pointer to an integer"},
+		{func() error { return PopInteger(&s, &integer) }, "stack: *int is not a pointer to an integer"},
+		{func() error { return PopArray(&s, &slice) }, "stack: *[]string is not a pointer to a slice of integers or Addresses"},
+		{func() error { return PopArray(&s, &array) }, "stack: *[2]uint8 is not a pointer to a slice of integers or Addresses"},
+		{func() error { return PopFixedArray(&s, &slice) }, "stack: *[]string is not a pointer to an array of integers or Addresses"},
+	}
+	for _, test := range tests {
+		if err := test.pop(); err == nil || err.Error() != test.err {
+			t.Errorf("Unexpected error: %v", err)
+		}
+	}
+	if s.Len() != 1 {
+		t.Errorf("Expected the item to stay on the stack after a type error")
+	}
+	for _, push := range []func(){
+		func() { PushInteger(&s, 1) },
+		func() { PushArray(&s, []int{1}) },
+		func() { PushArray(&s, uint8(1)) },
+	} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("Expected pushing an unsupported type to panic")
+				}
+			}()
+			push()
+		}()
+	}
+}
+
+func TestDecodeErrors(t *testing.T) {
+	tests := []struct {
+		data []byte
+		err  string
+	}{
+		{[]byte{1, 0}, "stack: 2 bytes left before the first item, expected a length"},
+		{[]byte{1, 5, 0, 0, 0}, "stack: item of 5 bytes does not fit in the 1 bytes before it"},
+	}
+	for _, test := range tests {
+		if _, err := Decode(test.data); err == nil || err.Error() != test.err {
+			t.Errorf("Unexpected error decoding %v: %v", test.data, err)
+		}
+	}
+	if s, err := Decode(nil); err != nil || s.Len() != 0 {
+		t.Errorf("Expected an empty stack, got %v, %v", s, err)
+	}
+}